@@ -5,29 +5,4467 @@
 ## You may modify, reuse and distribute the code freely as long as it is referenced back   ##
 ## to the author using the following line: ..based on gHybridWebSearch.sh by @drgfragkos   ##
 
-if [ "$1" == "" ]; then
-echo -ne "You need to pass a URL as an argument to work.\n  Usage: ./${0##*/} www.example.com\n"
+usage() {
+echo -ne "Usage: ./${0##*/} [options] www.example.com|https://www.example.com:8443\n"
+echo -ne "       ./${0##*/} rules update [--rules-url url] [--rules-key key.pem] [--fetch-allow hosts]\n"
+echo -ne "       ./${0##*/} wordlist fetch seclists:Discovery/Web-Content/raft-medium-files.txt [--seclists-rev rev]\n"
+echo -ne "                (downloads and caches a SecLists file, then usable as -d seclists:path)\n"
+echo -ne "       ./${0##*/} help [topic] | list profiles|wordlists|analyzers | completion bash|zsh|fish\n"
+echo -ne "       ./${0##*/} capabilities [--json]\n"
+echo -ne "       ./${0##*/} verify output-results.jsonl   (re-request previous hits: present, fixed or changed)\n"
+echo -ne "       ./${0##*/} query 'status==200 && length>10000 && path~\"backup\"' [output-results.jsonl] [--json]\n"
+echo -ne "       ./${0##*/} digest [output-results.jsonl] [--format markdown|html] [--mail address]\n"
+echo -ne "                (what appeared, was fixed or changed since the last digest, for scheduled scans)\n"
+echo -ne "       ./${0##*/} state set URL new|confirmed|false-positive|fixed|accepted-risk [note] | state list [state]\n"
+echo -ne "       ./${0##*/} [options] 192.168.1.0/24   (scans the rDNS and TLS certificate names of live hosts)\n"
+echo -ne "  -s, --scheme scheme     http or https (default: http, or the scheme of the URL)\n"
+echo -ne "  -p, --port port         port to connect to (default: 80 for http, 443 for https)\n"
+echo -ne "  -k, --insecure          do not verify the TLS certificate of the target (self-signed staging hosts)\n"
+echo -ne "  --sni name              send this name in the TLS handshake (SNI) instead of the Host of the requests,\n"
+echo -ne "                          and verify the certificate against it, e.g. CDN or front door routing tests\n"
+echo -ne "  --ca-cert file          verify the TLS certificate of the target against this CA (PEM)\n"
+echo -ne "  --client-cert file      client certificate (PEM) for mutual TLS\n"
+echo -ne "  --client-key file       private key of the client certificate (default: read from --client-cert)\n"
+echo -ne "  --tls-min-version v     lowest TLS version accepted: 1.0, 1.1, 1.2 or 1.3\n"
+echo -ne "  --proxy url             send every request through the proxy socks5://[user:pass@]host:port (a pivot\n"
+echo -ne "                          box), the target names being resolved by the proxy unless given an address\n"
+echo -ne "                          with --resolve; http:// proxies work too\n"
+echo -ne "  --proxy-file file       spread the requests round-robin across the proxies listed in the file, one\n"
+echo -ne "                          [scheme://][user:pass@]host:port per line (http by default); the dead ones\n"
+echo -ne "                          (unreachable or refusing the credentials) are skipped for the rest of the scan\n"
+echo -ne "  --tor                   send every request through the local Tor SOCKS port (--tor-socks, default:\n"
+echo -ne "                          127.0.0.1:9050), the names being resolved by Tor\n"
+echo -ne "  --tor-socks host:port   Tor SOCKS port of --tor (default: 127.0.0.1:9050); implies --tor\n"
+echo -ne "  --tor-control host:port  Tor control port of --tor-newnym (default: 127.0.0.1:9051)\n"
+echo -ne "  --tor-newnym n          ask Tor for new circuits (SIGNAL NEWNYM on --tor-control, default: 127.0.0.1:9051)\n"
+echo -ne "                          every n requests of a host, so long scans change exit nodes; the control port\n"
+echo -ne "                          password is read from \$TOR_CONTROL_PASSWORD, else the cookie \$TOR_COOKIE\n"
+echo -ne "                          (default: /run/tor/control.authcookie); Tor applies them 10 s apart at most\n"
+echo -ne "  --resolve host:ip       connect to ip for host (host:port:ip for one port only, IPv6 as [ip]), still\n"
+echo -ne "                          sending the host in the Host header and SNI, e.g. to reach the origin behind a CDN\n"
+echo -ne "  --source-ip ip          send the requests from this local address (repeatable: each host gets one of\n"
+echo -ne "                          them), where the egress must come from designated IPs; sent with curl\n"
+echo -ne "  --rotate-source         take the next --source-ip for every request instead of one per host\n"
+echo -ne "  --unix-socket path      connect to this Unix domain socket instead of the network (a service reachable\n"
+echo -ne "                          only through a local socket, e.g. a container sidecar), still sending the\n"
+echo -ne "                          target as Host; the requests are sent with curl\n"
+echo -ne "  --prefer-ipv4           connect to the IPv4 address of dual-stack targets, falling back to IPv6 when\n"
+echo -ne "                          there is none or it does not answer; the family is saved in output-results.jsonl\n"
+echo -ne "  --prefer-ipv6           the same, IPv6 first\n"
+echo -ne "  --resolver ip[:port]    resolve the targets with this DNS server (over TCP) instead of the system\n"
+echo -ne "                          resolver, e.g. the internal view of a split-horizon zone\n"
+echo -ne "  --doh url               resolve the targets with this DNS-over-HTTPS endpoint (RFC 8484), e.g.\n"
+echo -ne "                          https://cloudflare-dns.com/dns-query\n"
+echo -ne "  --dns-ttl s             resolve every host once and reuse its address for s seconds before asking again\n"
+echo -ne "                          (default: 300; 0 lets every request resolve the name, as with a proxy)\n"
+echo -ne "  --pin-dns               keep the address resolved at the start of each host for the whole scan, shown\n"
+echo -ne "                          next to the host, so every answer comes from the same server\n"
+echo -ne "  --http-version v        protocol of the requests: 1.0 (default), 1.1 or 2 (with curl: ALPN over https,\n"
+echo -ne "                          h2c upgrade over http); the protocol answered is shown in the status line\n"
+echo -ne "                          and the \"protocol\" of output-results.jsonl; 3 is --http3\n"
+echo -ne "  --http2                 same as --http-version 2\n"
+echo -ne "  --http3                 offer HTTP/3 (QUIC) to the https targets, falling back to HTTP/2 or 1.1 over\n"
+echo -ne "                          TCP; needs a curl built with HTTP3\n"
+echo -ne "  --connect-timeout s     give up a connection not established within s seconds (TCP and TLS)\n"
+echo -ne "  --response-header-timeout s  stall timeout: give up a request when the server sends less than a byte\n"
+echo -ne "                          per second for s seconds once connected, before the headers or within the body\n"
+echo -ne "                          (a server trickling its answer is not cut, bound the whole of it with\n"
+echo -ne "                          --request-timeout)\n"
+echo -ne "  --request-timeout s     give up a request not completed within s seconds; with any of the timeouts\n"
+echo -ne "                          the requests are sent with curl and the timed out ones saved in output-skipped.txt\n"
+echo -ne "  --transport command     send the requests with an external command instead of netcat, openssl or curl,\n"
+echo -ne "                          called as: command SCHEME ADDRESS PORT HOST with the raw request on its stdin,\n"
+echo -ne "                          printing the raw response (e.g. a client keeping connections alive)\n"
+echo -ne "  --no-preflight          skip the request of / made before the dictionary, which picks https or http for\n"
+echo -ne "                          the targets given without a scheme (https first) and stops the hosts that do\n"
+echo -ne "                          not resolve or answer, with the reason (refused, timed out, TLS..)\n"
+echo -ne "  --timings               time the DNS lookup, TCP connect, TLS handshake and server wait (time to first\n"
+echo -ne "                          byte) of every request, shown next to each answer and averaged per host in\n"
+echo -ne "                          output-timings.txt (the requests are sent with curl)\n"
+echo -ne "  --timestamps            show when every request was sent (UTC, milliseconds) and how long it took next to\n"
+echo -ne "                          its answer, to match target logs and WAF events (always in output-results.jsonl)\n"
+echo -ne "  --follow-redirects      follow the Location of the 3xx answers and show the chain of hops (status and\n"
+echo -ne "                          Location of each) next to the answer and in output-results.jsonl; the hops to\n"
+echo -ne "                          another host are sent without the credentials, headers, cookies and signature\n"
+echo -ne "  --max-redirects n       hops followed per answer with --follow-redirects (default: 5)\n"
+echo -ne "  -d, --dic file          dictionary to use (default: hybridWebSearch.dic), or the https URL of a wordlist\n"
+echo -ne "                          kept in ~/.gHybridWebSearch/wordlists (GHWS_CACHE) and downloaded again only\n"
+echo -ne "                          when it changed (ETag), e.g. the central wordlists of the team\n"
+echo -ne "  --dic-sha256 sum        refuse a wordlist whose SHA-256 is not sum (pinning of a -d URL)\n"
+echo -ne "                          -d seclists:path takes the file of the SecLists repository at --seclists-rev\n"
+echo -ne "  --seclists-rev rev      tag or commit of the SecLists files (default: 2024.3), pinned so every scan of\n"
+echo -ne "                          the team uses the same lists\n"
+echo -ne "  --dic-format format     plain, csv, jsonl, burp or zap (default: guessed from the file extension)\n"
+echo -ne "                          csv:   path,method,Name: value|Name: value,body, one entry per line, a field\n"
+echo -ne "                                 with commas or quotes in double quotes (RFC 4180, \"\" for a quote)\n"
+echo -ne "                          jsonl: {\"path\":..,\"method\":..,\"headers\":{\"Name\":\"value\"},\"body\":..}\n"
+echo -ne "  --variants list         also try every entry with a trailing slash (slash) and in lower, UPPER and Title\n"
+echo -ne "                          case (case), e.g. slash,case; a variant answering like its entry is merged into it\n"
+echo -ne "                          (output-skipped.txt), one answering differently is tagged [variant of /entry: ..]\n"
+echo -ne "  --url-encoding mode     how the entries are sent: path-escape (default) percent-encodes the bytes a path\n"
+echo -ne "                          cannot carry (space, #, a lone %, quotes, non-ASCII..), full-escape everything but\n"
+echo -ne "                          letters, digits, -._~ and /, raw sends them verbatim\n"
+echo -ne "  -x, --extensions list   also try every entry with each of the comma separated extensions (.bak,.old)\n"
+echo -ne "  --profile name          load a shareable scan profile (profiles/name.yml or a file); the options\n"
+echo -ne "                          given on the command line override the ones of the profile\n"
+echo -ne "  --import-burp file      use the in-scope paths of a Burp sitemap export (Save selected items, XML)\n"
+echo -ne "  --import-zap file       use the in-scope paths of a ZAP exported URL list (one URL per line)\n"
+echo -ne "  --head                  send HEAD instead of GET; hits (2xx/3xx/401/403) are re-requested with GET\n"
+echo -ne "                          to capture their size and title\n"
+echo -ne "  --no-escalate           do not re-request the HEAD hits with GET\n"
+echo -ne "  -X, --method m          send m (POST, PUT, PATCH, DELETE, OPTIONS..) instead of GET to the entries that\n"
+echo -ne "                          do not name their own method, e.g. API endpoints only answering POST\n"
+echo -ne "  -H, --header h          add the header \"Name: value\" to every request (repeatable), e.g. an API key,\n"
+echo -ne "                          custom auth or tenant header; the headers of a dictionary entry override it\n"
+echo -ne "  -A, --user-agent ua     send ua as the User-Agent of every request (some WAFs block the default one)\n"
+echo -ne "  --user-agent-file file  rotate the User-Agents of the file (one per line) per request, in a random\n"
+echo -ne "                          order (the same for a seed), or with --user-agent-rotation round-robin\n"
+echo -ne "  --user-agent-rotation random|round-robin  order the User-Agents of --user-agent-file are sent in:\n"
+echo -ne "                          drawn per request (default, the same for a seed) or in turn, line after line\n"
+echo -ne "  --spoof-ip ip           claim the client address ip in --spoof-headers (default: X-Forwarded-For,\n"
+echo -ne "                          X-Real-IP,Forwarded), to test the rate limits and ACLs trusting them: random\n"
+echo -ne "                          draws a public address per request, an IPv4 network (10.0.0.0/8) one of its own\n"
+echo -ne "  --spoof-headers list    comma separated headers carrying the --spoof-ip address (default: X-Forwarded-For,\n"
+echo -ne "                          X-Real-IP,Forwarded), any header name, e.g. X-Client-IP, True-Client-IP,\n"
+echo -ne "                          CF-Connecting-IP or X-Originating-IP; Forwarded is sent as for=ip\n"
+echo -ne "  --auth user:pass        send the credentials as Basic authentication with every request (\$GHWS_AUTH)\n"
+echo -ne "  --token token           send the token (a JWT..) as Authorization: Bearer with every request\n"
+echo -ne "                          (\$GHWS_TOKEN, keeping it out of the shell history and output-config.txt)\n"
+echo -ne "  --ntlm DOMAIN\\user:pass  authenticate every request with NTLM (Windows integrated authentication of\n"
+echo -ne "                          intranet IIS sites; \$GHWS_NTLM), the requests being sent with curl\n"
+echo -ne "  --digest user:pass      answer the Digest challenges (RFC 7616, MD5 or SHA-256) of the 401 answers with\n"
+echo -ne "                          the credentials and send the request again (\$GHWS_DIGEST), with curl\n"
+echo -ne "  --cookie pairs          send the cookies \"a=b; c=d\" with every request (repeatable), e.g. a session\n"
+echo -ne "                          copied from the browser, so the authenticated areas answer\n"
+echo -ne "  --cookie-jar file       keep the cookies the hosts set (Set-Cookie) and send them back with the next\n"
+echo -ne "                          requests of the host, the session being loaded from and saved to the file\n"
+echo -ne "                          (Netscape format, like curl -b/-c) so later scans reuse it\n"
+echo -ne "  --login-url path        log in to every host before the dictionary: post --login-data to path, keep the\n"
+echo -ne "                          session cookies it sets and scan with them (\"help login\")\n"
+echo -ne "  --login-data data       form fields of the login, {csrf} being replaced by the --login-csrf-regex token,\n"
+echo -ne "                          e.g. 'user=alice&password=...&token={csrf}' (\$GHWS_LOGIN_DATA)\n"
+echo -ne "  --login-success-regex re  a successful login answer matches re (default: one that sets a cookie)\n"
+echo -ne "  --login-csrf-regex re   fetch --login-url first and take the CSRF token from its first group of re,\n"
+echo -ne "                          e.g. 'name=\"csrf\" value=\"([^\"]+)\"'\n"
+echo -ne "  --logged-out re         an answer whose status line or headers match re (extended, case insensitive)\n"
+echo -ne "                          means the session expired: the host logs in again and resends the request\n"
+echo -ne "                          (default: a redirect to --login-url), e.g. '^HTTP/[0-9.]+ 401|^Location:.*/sso'\n"
+echo -ne "  --body data             body sent with every request but HEAD whose entry has none (its Content-Length\n"
+echo -ne "                          counted in bytes), e.g. '{\"id\": 1}'\n"
+echo -ne "  --body-file file        read the --body from a text file, kept as is (trailing newlines included); files\n"
+echo -ne "                          with NUL bytes are refused\n"
+echo -ne "  --content-type type     Content-Type of the bodies whose entry sets none (default: application/json\n"
+echo -ne "                          for a body starting with { or [, else application/x-www-form-urlencoded)\n"
+echo -ne "  --seed n                seed shuffling, jitter and every other random choice, so a scan can be exactly\n"
+echo -ne "                          reproduced (the seed and config hash are saved in output-config.txt)\n"
+echo -ne "  --shuffle               request the dictionary entries in random order\n"
+echo -ne "  --dedup                 drop the repeated dictionary entries (after -x), sorting them on disk so huge\n"
+echo -ne "                          generated wordlists need little memory\n"
+echo -ne "  --dedup-dir dir         directory of the temporary sort files of --dedup (default: \$TMPDIR or /tmp)\n"
+echo -ne "  --low-memory            for small VPS and drop-boxes: the answers are kept up to 256 KB for the analysis,\n"
+echo -ne "                          --dedup and --shuffle sort on disk with a 4 MB buffer, at most 4 hosts and one\n"
+echo -ne "                          deep analysis run at a time; the sizes logged stay those of the whole answers\n"
+echo -ne "  --jitter ms             add a random delay of up to ms milliseconds to every request\n"
+echo -ne "  --pause-on-5xx pct      pause a host for --cooldown seconds when more than pct% of its last --5xx-window\n"
+echo -ne "                          answers are 5xx, then resume it at half the rate (each storm halves it again)\n"
+echo -ne "  --5xx-window n          answers the 5xx proportion is measured over (default: 20)\n"
+echo -ne "  --cooldown s            pause of --pause-on-5xx, in seconds (default: 60)\n"
+echo -ne "  --no-resume             stop scanning the host on a 5xx storm instead of resuming it\n"
+echo -ne "  --no-adaptive           keep the pace on 429 and 503 answers; by default the host waits (Retry-After,\n"
+echo -ne "                          or 2, 4, 8.. seconds), retries the entry up to 3 times and halves its rate, then\n"
+echo -ne "                          doubles it again after every 20 answers without throttling; an entry still 429\n"
+echo -ne "                          or 503 is logged with that answer, marked [still 503 after 3 retries]\n"
+echo -ne "  --max-backoff s         longest wait on a 429 or 503, whatever the Retry-After (default: 300)\n"
+echo -ne "  --max-failures n        circuit breaker: after n consecutive requests without an answer (refused, reset,\n"
+echo -ne "                          timed out) pause the host for --down-wait seconds, and stop it when the next\n"
+echo -ne "                          request fails too (default: 10; 0 never stops)\n"
+echo -ne "  --down-wait s           pause of --max-failures before trying the host again (default: 30; 0 stops it)\n"
+echo -ne "  --backfill n            rounds of retries, at the end of each host and at its current pace, of the entries\n"
+echo -ne "                          dropped for no answer (default: 1; 0 for none); those still left,\n"
+echo -ne "                          and the rest of a host stopped early, are saved in output-retry.jsonl\n"
+echo -ne "  --rate n                at most n requests per second in all, shared by every host and thread, in\n"
+echo -ne "                          place of the 100 ms pause of each host, e.g. the req/s of the rules of engagement\n"
+echo -ne "                          (fractions too: 0.5 is a request every 2 seconds)\n"
+echo -ne "  --max-connects n        open at most n new connections per second, across all the hosts and threads\n"
+echo -ne "                          (every request, escalation or probe opens one), to keep clear of IDS SYN rules\n"
+echo -ne "  --max-bandwidth rate    download at most rate bytes per second in all (512k, 2m..), every answer being\n"
+echo -ne "                          read in 16 KB blocks on a schedule shared by all the hosts and threads\n"
+echo -ne "  --hosts file            scan every host (or CIDR range) listed in the file, one per line\n"
+echo -ne "                          a hosts x interesting paths matrix is saved in output-matrix.csv\n"
+echo -ne "  -t, --threads n         number of hosts scanned in parallel with --hosts (default: 1)\n"
+echo -ne "  --path path             only check the given path(s), repeatable or comma separated; quick\n"
+echo -ne "                          verification mode, e.g. --path /.env --hosts all.txt (default threads: 20)\n"
+echo -ne "  --exclude-hosts file    never touch the hostnames, IPs or CIDRs (IPv4, IPv6) listed in the file; checked after\n"
+echo -ne "                          DNS resolution and the scan connects only to the address that was checked\n"
+echo -ne "  --fetch-allow hosts     the only hosts (comma separated, *.domain, repeatable; GHWS_FETCH_ALLOW) the script\n"
+echo -ne "                          itself downloads from (rules, wordlists..), over https only and redirects\n"
+echo -ne "                          checked too; without it any host whose addresses are not internal\n"
+echo -ne "  --rules-url url         https source of rules.tar.gz and rules.tar.gz.sig for \"rules update\"\n"
+echo -ne "                          (default: \$GHWS_RULES_URL)\n"
+echo -ne "  --rules-key key.pem     public key the rules.tar.gz.sig of \"rules update\" is verified with\n"
+echo -ne "                          (default: rules.pub next to the rules)\n"
+echo -ne "  --mode vhost            keep the URL fixed (--vhost-path, default /) and fuzz the Host header with the\n"
+echo -ne "                          dictionary (a bare word becomes word.domain), reporting the names answering\n"
+echo -ne "                          differently than an unknown name: hidden virtual hosts on the same IP\n"
+echo -ne "  --vhost-path path       path requested with every Host name of --mode vhost (default: /), e.g. a page\n"
+echo -ne "                          only the right virtual host serves\n"
+echo -ne "  --mode pair             send every entry twice, as is and with the --pair-with difference, and report\n"
+echo -ne "                          only the paths whose two answers differ (output-pairdiff.txt)\n"
+echo -ne "  --pair-with variant     the difference of --mode pair: slash (toggle the trailing slash, the default),\n"
+echo -ne "                          scheme[:port] (the other of http/https), header:Name: value or method:NAME\n"
+echo -ne "  --active-checks list    opt in to checks that attack the hits (comma separated, repeatable): host-header\n"
+echo -ne "                          requests every 2xx/3xx hit again with a forged Host, then X-Forwarded-Host,\n"
+echo -ne "                          and flags the answers redirecting or linking to it; open-redirect puts a\n"
+echo -ne "                          canary URL in the redirect-like parameters (next, url, returnTo..) of the\n"
+echo -ne "                          hits and flags those redirecting to it (output-active.txt); without it, the\n"
+echo -ne "                          3xx whose Location is the URL given in such a parameter are still tagged\n"
+echo -ne "  --vhost-diff            also request every path from the IP default site and save the paths that answer\n"
+echo -ne "                          differently than the named vhost in output-vhostdiff.txt\n"
+echo -ne "  --top n                 size of the digest of the most interesting findings printed at the end of the\n"
+echo -ne "                          scan and saved in output-top.txt (default: 20, 0 for none), ranked by secrets,\n"
+echo -ne "                          leaks, sensitive names, rare answers and size outliers\n"
+echo -ne "  --success-expr expr     decide which answers are hits (shown and logged) with an expression in place of\n"
+echo -ne "                          \"not 404\": 'code in (200,204) || (code==403 && length!=baseline403)'; fields: code,\n"
+echo -ne "                          length, path, method, title, type, baseline (length of a random path) and\n"
+echo -ne "                          baselineNNN (length of the first NNN answer of the host); == != < > <= >= ~ !~ in\n"
+echo -ne "  --log-all-statuses      also print and log the 404 Not Found answers (hidden by default); the\n"
+echo -ne "                          dictionary entries tried per host are always summarised in output-coverage.txt\n"
+echo -ne "                          every skipped request is saved with its reason in output-skipped.txt\n"
+echo -ne "  --fleet-dedup           fingerprint every response and collapse hosts that answer like the rest of\n"
+echo -ne "                          the fleet, reporting only the hosts that differ (output-fleet.txt)\n"
+echo -ne "  --header-diff           compare the cookies and notable headers (Server, X-Powered-By, Via, debug..)\n"
+echo -ne "                          of every hit with those of the site root and flag the differences, which often\n"
+echo -ne "                          reveal a separate backend or legacy application (output-headerdiff.txt)\n"
+echo -ne "  --allowed-methods       send OPTIONS for every 200, 401 and 403 hit and record its Allow header, shown\n"
+echo -ne "                          as [allow: ..] and in output-results.jsonl (allow), the dangerous verbs (PUT,\n"
+echo -ne "                          DELETE, TRACE, the WebDAV ones..) also as [dangerous methods: ..]\n"
+echo -ne "  --disclosure            check the headers and body of every response, 404 included, for internal IPs,\n"
+echo -ne "                          hostnames, filesystem paths and stack traces (disclosure.rules); such answers\n"
+echo -ne "                          are always shown, tagged, and saved in output-disclosure.txt\n"
+echo -ne "  --entropy               flag the small text bodies (up to 4 KB, not markup) of the GET answers whose\n"
+echo -ne "                          Shannon entropy reaches --entropy-min bits per character: likely tokens, keys\n"
+echo -ne "                          or encrypted blobs, rated as secrets in output-defectdojo.json\n"
+echo -ne "  --entropy-min bits      entropy flagged by --entropy (default: 5.0; random base64 is about 6)\n"
+echo -ne "  --error-pages           request a few malformed paths (overlong names, illegal characters, reserved\n"
+echo -ne "                          device names) per host to provoke verbose error pages, and show the framework\n"
+echo -ne "                          and version strings they reveal (fingerprint.rules) in the host summary\n"
+echo -ne "  --detect-language       detect the language of every hit (html lang, Content-Language or the text)\n"
+echo -ne "  --language list         show only the hits in one of the comma separated languages (en,de); the hits\n"
+echo -ne "                          of unknown language are always shown, the others only with --log-all-statuses\n"
+echo -ne "  --analyzer name         run an analyzer on every hit (repeatable): secrets, listing, title, tech, backups,\n"
+echo -ne "                          sourcemaps, webdav, aspnet, php, spring or an executable of ./analyzers,\n"
+echo -ne "                          next to the script or ~/.gHybridWebSearch/analyzers\n"
+echo -ne "                          (\"help analyzers\"); its tags and findings are shown and saved in\n"
+echo -ne "                          output-analyzers.txt, and the paths it asks for are requested after the dictionary\n"
+echo -ne "  --follow-up-depth n     levels of the follow-ups asked for by the analyzers that are requested: 1 for\n"
+echo -ne "                          those of the dictionary only, 2 for those of the follow-ups too (default: 2,\n"
+echo -ne "                          0 requests none)\n"
+echo -ne "  --analyzer-budget s     stop running an analyzer on a host once it has taken s seconds there (the time\n"
+echo -ne "                          of every module is in output-profile.txt)\n"
+echo -ne "  --sourcemap-dir dir     with --analyzer sourcemaps, rebuild the original sources of the source maps\n"
+echo -ne "                          found under dir/HOST (sourcesContent)\n"
+echo -ne "  --deep                  send the hits to a deep analysis stage (body fetch and secrets scan) running\n"
+echo -ne "                          next to the discovery with its own concurrency; results in output-deep.txt\n"
+echo -ne "  --deep-trigger regex    status codes sent to the deep stage (default with --deep: ^(200|401|403)$)\n"
+echo -ne "  --deep-threads n        concurrent deep analysis jobs (default: 2)\n"
+echo -ne "  --deep-command command  extra analysis per hit (screenshots, bypass attempts..), called as:\n"
+echo -ne "                          command HOST ADDRESS PORT PATH SCHEME with the raw response on stdin\n"
+echo -ne "  --keep-partial          keep the outputs of an interrupted scan (in .partial.PID); by default they are\n"
+echo -ne "                          written there and only moved into place once the scan completes\n"
+echo -ne "  --state-file file       lifecycle states of the findings (default: findings-state.txt)\n"
+echo -ne "  --defectdojo            export the hits as DefectDojo Generic Findings Import JSON (output-defectdojo.json)\n"
+echo -ne "  --defectdojo-url url    also import them into DefectDojo, with --defectdojo-engagement and\n"
+echo -ne "                          --defectdojo-token\n"
+echo -ne "  --defectdojo-engagement id  DefectDojo engagement the findings of --defectdojo-url are imported into\n"
+echo -ne "  --defectdojo-token token  API v2 token of --defectdojo-url (default: \$DEFECTDOJO_TOKEN)\n"
+echo -ne "  --create-issues tracker open a jira or github issue per high severity finding (secrets exposed),\n"
+echo -ne "                          skipping the findings that already have an open issue\n"
+echo -ne "  --github-repo repo      owner/repo of the github issues (token: \$GITHUB_TOKEN)\n"
+echo -ne "  --jira-url url          Jira server of the jira issues (credentials: \$JIRA_USER, \$JIRA_TOKEN)\n"
+echo -ne "  --jira-project key      Jira project of the jira issues\n"
+echo -ne "  --hmac-key key          sign every request with HMAC-SHA256 using the given key\n"
+echo -ne "  --hmac-header name      header carrying the HMAC signature (default: X-Signature)\n"
+echo -ne "  --signer command        external signer, called as: command METHOD PATH HOST\n"
+echo -ne "                          every line it prints is added to the request as a header\n"
+echo -ne "  --aws-sigv4 region/service  sign every request with AWS Signature Version 4 (API Gateway: execute-api,\n"
+echo -ne "                          S3: s3), credentials from \$AWS_ACCESS_KEY_ID, \$AWS_SECRET_ACCESS_KEY and\n"
+echo -ne "                          \$AWS_SESSION_TOKEN\n"
+}
+
+version=0.2
+commands="help list completion rules capabilities verify query digest wordlist state"
+script_dir=${0%/*}
+rules_dir=$script_dir
+rules_url=$GHWS_RULES_URL
+fetch_allow=$GHWS_FETCH_ALLOW
+rules_key="$rules_dir/rules.pub"
+state_file="findings-state.txt"
+keep_partial=0
+out=""
+stage=""
+work=".ghws.$$"
+log_file=".log.dat"
+case "`uname -s`" in
+MINGW*|MSYS*|CYGWIN*) log_file="output-log.txt" ;;
+esac
+scan_outputs="$log_file output-200.txt output-ex404.txt output-results.jsonl output-defectdojo.json output-config.txt output-coverage.txt output-skipped.txt output-matrix.csv output-vhostdiff.txt output-pairdiff.txt output-headerdiff.txt output-disclosure.txt output-errorpages.txt output-deep.txt output-fleet.txt output-timings.txt output-top.txt output-analyzers.txt output-retry.jsonl output-correlation.csv output-active.txt output-sourcemaps.txt output-profile.txt"
+defectdojo=0
+defectdojo_url=""
+defectdojo_token=$DEFECTDOJO_TOKEN
+defectdojo_engagement=""
+create_issues=""
+github_api=${GITHUB_API_URL:-https://api.github.com}
+github_repo=""
+jira_url=""
+jira_project=""
+server=""
+scheme=http
+port=80
+port_option=""
+dns_ttl=300
+pin_dns=0
+scheme_given=0
+preflight=1
+insecure=0
+sni=""
+tls_options=()
+curl_options=()
+tls_min_version=""
+http_version=""
+connect_timeout=""
+header_timeout=""
+request_timeout=""
+transport=""
+timings=0
+timestamps=0
+follow_redirects=0
+max_redirects=5
+proxy=""
+proxy_file=""
+tor=0
+tor_socks=127.0.0.1:9050
+pinned_address=0
+tor_control=127.0.0.1:9051
+tor_newnym=""
+resolves=""
+source_ips=()
+source_turn=0
+correlation=""
+rotate_source=0
+unix_socket=""
+prefer_family=""
+resolver=""
+doh=""
+proxies=()
+proxy_turn=0
+counter=0
+dic="hybridWebSearch.dic"
+dic_format=""
+url_encoding=path-escape
+dic_sha256=""
+seclists_url="https://raw.githubusercontent.com/danielmiessler/SecLists"
+seclists_rev="2024.3"
+wordlist_cache=${GHWS_CACHE:-$HOME/.gHybridWebSearch/wordlists}
+extensions=""
+variants=""
+variant_key=""
+variant_base=""
+variant_signature=""
+slash_base=""
+slash_signature=""
+seed=""
+shuffle=0
+dedup=0
+dedup_dir=${TMPDIR:-/tmp}
+low_memory=0
+sort_buffer=64M
+body_limit=""
+jitter=0
+rate=""
+max_connects=""
+max_bandwidth=""
+storm_threshold=""
+storm_window=20
+cooldown=60
+storm_resume=1
+adaptive=1
+max_backoff=300
+backfill_rounds=1
+max_failures=10
+down_wait=30
+sep=$'\037'
+hmac_key=""
+hmac_header="X-Signature"
+signer=""
+aws_sigv4=""
+hosts=""
+fleet_dedup=0
+header_diff=0
+check_methods=0
+check_disclosure=0
+check_entropy=0
+entropy_min=5.0
+error_mining=0
+detect_language=0
+languages=""
+paths=""
+threads=""
+exclude_hosts=""
+vhost_diff=0
+active_checks=""
+top=20
+mode=path
+vhost_path=/
+pair_with=slash
+log_all=0
+success_expr=""
+success_parts=()
+default_method=GET
+custom_headers=()
+user_agents=()
+user_agent_file=""
+agent_rotation=random
+agent_seed=0
+spoof_ip=""
+spoof_headers="X-Forwarded-For,X-Real-IP,Forwarded"
+auth=$GHWS_AUTH
+token=$GHWS_TOKEN
+authorization=""
+ntlm=$GHWS_NTLM
+digest=$GHWS_DIGEST
+cookies=""
+cookie_jar=""
+login_url=""
+login_data=$GHWS_LOGIN_DATA
+login_success_regex=""
+login_csrf_regex=""
+logged_out_regex=""
+login_reason=""
+login_status=""
+declare -A session_cookies
+default_body=""
+body_file=""
+content_type=""
+deep_trigger=""
+deep_threads=2
+builtin_analyzers="secrets listing title tech backups sourcemaps webdav aspnet php spring"
+analyzers=()
+sourcemap_dir=""
+analyzer_budget=""
+follow_up_depth=2
+depth=0
+declare -A analyzer_paths
+deep_command=""
+escalate=1
+
+## private_address IP - succeeds for the loopback, private, link-local (cloud metadata), CGNAT,     ##
+## benchmark, IETF, multicast, reserved, broadcast and unspecified addresses, IPv4 or IPv6 (the IPv4 ##
+## mapped ones included); IP is a dotted address, as getent prints it                                ##
+private_address() {
+local ip=${1,,} a b c d
+ip=${ip#[}
+ip=${ip%]}
+ip=${ip#::ffff:}
+if [[ "$ip" =~ ^([0-9a-f]{1,4}):([0-9a-f]{1,4})$ ]]; then
+a=$(( 16#${BASH_REMATCH[1]} ))
+b=$(( 16#${BASH_REMATCH[2]} ))
+ip="$(( a >> 8 )).$(( a & 255 )).$(( b >> 8 )).$(( b & 255 ))"
+fi
+if [[ "$ip" =~ ^[0-9]+\.[0-9]+\.[0-9]+\.[0-9]+$ ]]; then
+IFS=. read -r a b c d <<< "$ip"
+a=$(( 10#$a ))
+b=$(( 10#$b ))
+c=$(( 10#$c ))
+[ $a -eq 0 ] || [ $a -eq 10 ] || [ $a -eq 127 ] || [ $a -ge 224 ] || { [ $a -eq 100 ] && [ $b -ge 64 ] && [ $b -le 127 ]; } || { [ $a -eq 169 ] && [ $b -eq 254 ]; } || { [ $a -eq 172 ] && [ $b -ge 16 ] && [ $b -le 31 ]; } || { [ $a -eq 192 ] && [ $b -eq 168 ]; } || { [ $a -eq 192 ] && [ $b -eq 0 ] && [ $c -eq 0 ]; } || { [ $a -eq 198 ] && [ $b -ge 18 ] && [ $b -le 19 ]; }
+else
+[ "$ip" == "::1" ] || [ "$ip" == "::" ] || [[ "$ip" =~ ^f[cd]|^fe[89ab]|^ff|^64:ff9b: ]]
+fi
+}
+
+## fetch_check URL - checks an outbound URL of the scanner itself (rules, wordlists..) against the ##
+## --fetch-allow list: https only, no credentials, and a host that is listed (host or *.domain), or ##
+## any host when there is no list, as long as none of its addresses is private; prints the        ##
+## "host:port:address" to pin the connection to, or the reason of the refusal                     ##
+fetch_check() {
+local rest=${1#*://} authority host port entry listed=0 address addresses
+if [[ "${1,,}" != https://* ]]; then
+echo "only https URLs are fetched"
+return 1
+fi
+authority=${rest%%[/?#]*}
+if [[ "$authority" == *@* ]]; then
+echo "credentials in the URL"
+return 1
+fi
+host=${authority%:*}
+port=${authority##*:}
+if [ "$host" == "$authority" ] || [[ "$authority" == *\] ]]; then
+host=$authority
+port=443
+fi
+host=${host#[}
+host=${host%]}
+host=${host,,}
+if ! [[ "$port" =~ ^[0-9]+$ ]] || [ "$host" == "" ]; then
+echo "invalid URL"
+return 1
+fi
+for entry in ${fetch_allow//,/ }; do
+entry=${entry,,}
+if [ "$host" == "$entry" ] || { [[ "$entry" == \*.* ]] && [[ "$host" == *"${entry#\*}" ]]; }; then
+listed=1
+fi
+done
+if [ "$fetch_allow" != "" ] && [ "$listed" == "0" ]; then
+echo "$host is not in --fetch-allow"
+return 1
+fi
+addresses=`getent ahosts "$host" | awk '{ print $1 }' | sort -u`
+if [ "$addresses" == "" ]; then
+echo "$host does not resolve"
+return 1
+fi
+for address in $addresses; do
+if [ "$listed" == "0" ] && private_address "$address"; then
+echo "$host resolves to the internal address $address (list it in --fetch-allow to allow it)"
+return 1
+fi
+done
+address=${addresses%%$'\n'*}
+if [[ "$address" == *:* ]]; then
+address="[$address]"
+fi
+echo "$host:$port:$address"
+}
+
+## fetch_url URL FILE [CURL OPTIONS] - downloads URL into FILE, checking it and every redirect with ##
+## fetch_check and connecting only to the address that was checked; the status is in $fetch_status  ##
+fetch_url() {
+local url=$1 file=$2 pin hops=0 next
+shift 2
+fetch_status=""
+while true; do
+if ! pin=`fetch_check "$url"`; then
+echo -ne "Refused to fetch $url: $pin\n"
+return 1
+fi
+next=`curl -fsS --proto =https --max-redirs 0 --resolve "$pin" -o "$file" -w '%{http_code} %{redirect_url}' "$@" "$url"` || return 1
+fetch_status=${next%% *}
+next=${next#* }
+if [ "$next" == "" ]; then
+return 0
+fi
+hops=$(( hops + 1 ))
+if [ $hops -gt 5 ]; then
+echo -ne "Refused to fetch $1: more than 5 redirects\n"
+return 1
+fi
+url=$next
+done
+}
+
+## fetch_wordlist - replaces a -d URL with its copy in the wordlist cache, downloaded again only when ##
+## the server says it changed (ETag), and checked against --dic-sha256 when pinned                   ##
+fetch_wordlist() {
+local url=$dic name cache etag sum
+name=${url%%[?#]*}
+name=${name##*/}
+cache="$wordlist_cache/`echo -n "$url" | sha256sum | cut -c1-16`-${name:-wordlist}"
+mkdir -p "$wordlist_cache"
+if [ -f "$cache" ] && [ -f "$cache.etag" ]; then
+etag=`cat "$cache.etag"`
+fi
+if fetch_url "$url" "$cache.part" -D "$cache.headers" ${etag:+-H "If-None-Match: $etag"}; then
+if [ "$fetch_status" == "304" ]; then
+echo -ne "Wordlist: $url not modified, using the cached copy\n"
+rm -f "$cache.part"
+else
+mv -f "$cache.part" "$cache"
+grep -i '^ETag:' "$cache.headers" | tail -1 | sed 's/^[^:]*:[ \t]*//; s/\r$//' > "$cache.etag"
+echo -ne "Wordlist: $url downloaded (`wc -l < "$cache"` entries)\n"
+fi
+elif [ -f "$cache" ]; then
+echo -ne "Wordlist: could not fetch $url, using the cached copy\n"
+rm -f "$cache.part"
+else
+echo -ne "Could not fetch the wordlist $url\n"
+rm -f "$cache.part" "$cache.headers"
+exit 1
+fi
+rm -f "$cache.headers"
+if [ "$dic_sha256" != "" ]; then
+sum=`sha256sum < "$cache" | cut -d' ' -f1`
+if [ "${dic_sha256,,}" != "$sum" ]; then
+echo -ne "Wordlist checksum mismatch for $url: $sum (expected $dic_sha256), the wordlist was not used\n"
+rm -f "$cache" "$cache.etag"
+exit 1
+fi
+fi
+dic=$cache
+}
+
+## update_rules - fetches rules.tar.gz from $rules_url, verifies its signature against $rules_key ##
+## and installs the dictionaries, profiles and rule files it carries next to the script        ##
+update_rules() {
+local tmp file
+if [ "$rules_url" == "" ] || [ ! -f "$rules_key" ]; then
+echo -ne "A rules source (--rules-url or GHWS_RULES_URL) and its public key (--rules-key, default: $rules_dir/rules.pub) are needed.\n"
+exit 1
+fi
+tmp=`mktemp -d`
+echo -ne "Fetching $rules_url/rules.tar.gz\n"
+if ! fetch_url "$rules_url/rules.tar.gz" "$tmp/rules.tar.gz" || ! fetch_url "$rules_url/rules.tar.gz.sig" "$tmp/rules.tar.gz.sig"; then
+echo -ne "Could not fetch the rules.\n"
+rm -rf "$tmp"
+exit 1
+fi
+if ! openssl dgst -sha256 -verify "$rules_key" -signature "$tmp/rules.tar.gz.sig" "$tmp/rules.tar.gz" > /dev/null 2>&1; then
+echo -ne "Signature verification failed, the rules were not installed.\n"
+rm -rf "$tmp"
+exit 1
+fi
+mkdir "$tmp/rules"
+tar -xzf "$tmp/rules.tar.gz" -C "$tmp/rules" --no-same-owner --no-same-permissions
+(cd "$tmp/rules" && find . -type f) | sed 's|^\./||' | grep -E '^([A-Za-z0-9_.-]+\.(dic|rules)|profiles/[A-Za-z0-9_.-]+\.yml)$' | while read file; do
+mkdir -p "$rules_dir/${file%/*}"
+cp "$tmp/rules/$file" "$rules_dir/$file"
+echo -ne "Updated: $file\n"
+done
+rm -rf "$tmp"
+}
+
+## help_topic TOPIC - prints the help page of a topic ##
+help_topic() {
+case "$1" in
+"")
+usage
+echo -ne "\nHelp topics: dictionaries, hosts, vhost, head, deep, analyzers, profiles, rules, signing, login, states, output\n"
+;;
+dictionaries) cat <<'EOF'
+Dictionaries (-d, --dic-format, --dic-sha256, --url-encoding, -x, --path, --import-burp, --import-zap)
+  plain   one path per line (hybridWebSearch.dic)
+  csv     path,method,Name: value|Name: value,body - per entry method, headers and body, one entry
+          per line; a field with commas or quotes goes in double quotes ("" for a quote), e.g.
+          api/users,POST,Content-Type: application/json,"{""name"": ""a"", ""role"": ""b""}"
+  jsonl   {"path":"/api","method":"POST","headers":{"Name":"value"},"body":"..."}
+  burp    a Burp sitemap export (Save selected items, XML), only the paths of the target are kept
+  zap     a ZAP exported URL list, only the paths of the target are kept
+  The format is guessed from the extension (.csv, .jsonl, .xml). -x appends every extension to
+  every entry, --path replaces the dictionary with the given paths. --variants slash,case follows
+  every entry with /entry/, /entry in lower, UPPER and Title case; the variants answering like
+  their entry (status, Content-Length, Location) are merged into it, the others are shown tagged.
+  --url-encoding path-escape (the default) percent-encodes the bytes of an entry that a path
+  cannot carry: "admin panel#1" is sent as /admin%20panel%231, %2e stays an escape and 100% is
+  sent as 100%25. full-escape encodes everything but letters, digits, -._~ and / (?, & and = too)
+  and raw sends the entries as they are, for hand-made encodings and parser tests.
+  -d also takes the https URL of a wordlist (behind --fetch-allow): it is cached in
+  ~/.gHybridWebSearch/wordlists (GHWS_CACHE), revalidated with its ETag on every scan, used from
+  the cache when the server cannot be reached, and refused when --dic-sha256 does not match.
+  seclists:path names a file of the SecLists repository at the --seclists-rev tag or commit
+  (raw.githubusercontent.com), e.g. -d seclists:Discovery/Web-Content/raft-medium-files.txt;
+  "wordlist fetch seclists:path" only downloads it into the cache, e.g. ahead of an offline run.
+EOF
+;;
+hosts) cat <<'EOF'
+Multiple hosts (--hosts, -t, --exclude-hosts, --fleet-dedup)
+  --hosts reads one host or CIDR range per line and -t scans that many hosts in parallel. CIDR
+  ranges are expanded to their live addresses, scanned by the names found in reverse DNS and in
+  their TLS certificates. --exclude-hosts is checked after DNS resolution and the connection is
+  pinned to the checked address. output-matrix.csv lists hosts x interesting paths and
+  --fleet-dedup collapses the hosts answering like the rest of the fleet (output-fleet.txt).
+EOF
+;;
+vhost) cat <<'EOF'
+Virtual hosts (--vhost-diff, --mode vhost, --mode pair)
+  Every path is also requested from the IP default site (Host: the address) and the paths whose
+  status differs from the named vhost are saved in output-vhostdiff.txt. Content answering only
+  by IP is tagged [only reachable by IP], often a forgotten legacy application.
+  --mode vhost connects to the address of the target, requests --vhost-path with every dictionary
+  entry as Host (and SNI; a bare word becomes word.domain, the target without www.) and reports
+  the names whose status or size differs from the baseline of an unknown name. The certificate is
+  not verified in this mode, the candidate names rarely match it.
+  --mode pair sends every entry twice with a controlled difference (--pair-with slash, scheme,
+  header:Name: value or method:NAME) and reports only the paths whose status or size differ
+  between the two answers, e.g. an ACL enforced on /admin but not on /admin/, a page served over
+  http only, or a header the backend trusts. The pairs go to output-pairdiff.txt.
+EOF
+;;
+head) cat <<'EOF'
+HEAD mode (--head, --no-escalate)
+  HEAD is used for the bulk of the requests and the hits (2xx/3xx/401/403) are re-requested with
+  GET to capture their size and title, unless --no-escalate is given.
+EOF
+;;
+deep) cat <<'EOF'
+Deep analysis (--deep, --deep-trigger, --deep-threads, --deep-command)
+  The hits whose status matches --deep-trigger are queued to a second stage running next to the
+  discovery with its own concurrency. It fetches the body, scans it with secrets.rules and runs
+  --deep-command HOST ADDRESS PORT PATH SCHEME with the raw response on stdin (output-deep.txt).
+EOF
+;;
+analyzers) cat <<'EOF'
+Analyzers (--analyzer, list analyzers)
+  An analyzer inspects every answer that is not a 404 and prints one line per result:
+    tag TEXT        shown next to the hit as [name: TEXT]
+    finding TEXT    shown as [name finding: TEXT] and rated as a secret (output-defectdojo.json)
+    request PATH    a follow-up path, requested once the dictionary is done; the follow-ups of
+                    the follow-ups are requested too, up to --follow-up-depth levels (2)
+  The built-ins are secrets (secrets.rules), listing (directory listings, whose entries become
+  follow-ups), title, tech (fingerprint.rules), backups (the .bak, .old, .orig, .save, ~, .tmp,
+  .swp and .map variants of the file hit and of the files its page links to, far more precise
+  than a generic list of backup names), sourcemaps (the source map of every JavaScript hit is
+  asked for, and the original sources of those found are reported, listed in
+  output-sourcemaps.txt and, with --sourcemap-dir, rebuilt on disk) and webdav (the directories
+  and the answers advertising DAV get OPTIONS and a PROPFIND Depth: 1, whose multistatus
+  resources become follow-ups) and aspnet (on the IIS / ASP.NET answers: trace.axd, elmah.axd
+  and the web.config backups are asked for and reported with their evidence, and the ViewState
+  of the pages is decoded) and php (on the PHP answers: the phpinfo() pages, composer.json and
+  .lock, the .phps source of the script and the Laravel Telescope / Horizon and Symfony profiler
+  debug tools) and spring (on the Java / Spring answers: the actuator endpoints, env, configprops
+  and heapdump, asked for with HEAD, reported when exposed, and the routes of mappings requested
+  as follow-ups). Any other name is an executable found in
+  ./analyzers, next to the script or in ~/.gHybridWebSearch/analyzers, called with the raw
+  response file (no body for HEAD) and GHWS_URL, GHWS_METHOD and GHWS_STATUS set; what it
+  writes to stderr is discarded. Every line is also saved in output-analyzers.txt.
+EOF
+;;
+profiles) cat <<'EOF'
+Profiles (--profile)
+  Flat YAML files naming the long options of the script (option: value, option: [a, b] or a
+  "- item" list), searched in ./profiles, next to the script and in ~/.gHybridWebSearch/profiles.
+  The options given on the command line override the profile. "list profiles" shows them.
+  A pipeline: list composes the same options as named stages, in the order seed (dic, path,
+  hosts..), mutate (extensions, dedup, shuffle..), probe (head, rate, http-version, timeouts..),
+  analyze (disclosure, entropy, deep..) and sink (top, state-file, defectdojo, create-issues..):
+    pipeline:
+      - seed: dic ../hybridWebSearch.dic
+      - mutate: extensions [.bak, .old]
+      - probe polite:
+          rate: 5
+          follow-redirects: true
+      - analyze: disclosure
+      - sink: top 10
+  An option in the wrong stage or a stage out of order stops the scan with the line at fault.
+  See profiles/secrets-pipeline.yml.
+EOF
+;;
+rules) cat <<'EOF'
+Rules (rules update, --rules-url, --rules-key)
+  Fetches rules.tar.gz and rules.tar.gz.sig from the rules source and installs the *.dic,
+  *.rules and profiles/*.yml files it carries, only when the signature verifies:
+    $ openssl dgst -sha256 -sign private.pem -out rules.tar.gz.sig rules.tar.gz
+  Everything the script downloads by itself goes through the same guard, so a target or a
+  tampered source cannot bounce it into the internal network: https only, no credentials in
+  the URL, every redirect checked again, the connection pinned to the checked address, and
+  either a host of --fetch-allow (host or *.domain) or, without the list, a host with no
+  loopback, private, link-local (169.254.169.254..), CGNAT, multicast or reserved address;
+  numeric hosts (2130706433, 0x7f.1..) are checked by the address they resolve to.
+EOF
+;;
+login) cat <<'EOF'
+Scripted login (--login-url, --login-data, --login-success-regex, --login-csrf-regex)
+  Before its dictionary, every host is logged in to with a form POST of --login-data to
+  --login-url. With --login-csrf-regex the login page is fetched first, its cookies kept and
+  the first group of the regex (or the whole match), percent-encoded, replaces {csrf} in --login-data:
+    --login-url /login --login-csrf-regex 'name="_token" value="([^"]+)"'
+    --login-data 'email=alice%40example.com&password=...&_token={csrf}'
+  The login succeeds when its answer (headers and body) matches --login-success-regex, or by
+  default when it sets a cookie; otherwise the host is skipped. The cookies set by the login and
+  by every later answer are sent with the requests of the host (--cookie-jar saves them).
+  When an answer matches --logged-out (by default, a redirect to --login-url) the session is
+  considered expired: the host logs in again and resends the request with the fresh session.
+  It stops, saving its remaining entries in output-retry.jsonl, when the login fails or after 3
+  entries in a row are still logged out right after logging in again.
+EOF
+;;
+states) cat <<'EOF'
+Finding states (state set, state list, --state-file)
+  Every hit is "new" until triaged with: state set URL confirmed|false-positive|fixed|accepted-risk
+  [note]. The states are shown on the screen and carried in output-results.jsonl; "verify" marks
+  the hits that disappeared as fixed and the fixed hits that came back as new again. Only "state
+  set" creates --state-file: without it nothing is tracked and no file is written.
+EOF
+;;
+signing) cat <<'EOF'
+Request signing (--hmac-key, --hmac-header, --signer, --aws-sigv4)
+  --hmac-key adds an HMAC-SHA256 of "METHOD\nPATH\nHOST" (hex) in --hmac-header. --signer is
+  called as "command METHOD PATH HOST" for every request and each line it prints is a header.
+  --aws-sigv4 region/service signs every request with AWS Signature Version 4 (host, X-Amz-Date
+  and X-Amz-Content-Sha256, plus X-Amz-Security-Token with temporary credentials), using
+  AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN from the environment, e.g.
+    AWS_ACCESS_KEY_ID=.. AWS_SECRET_ACCESS_KEY=.. ./gHybridWebSearch.sh https://abc.execute-api.eu-west-1.amazonaws.com --aws-sigv4 eu-west-1/execute-api
+  The path is signed the AWS way: every segment URI-encoded twice (once for s3) and the query
+  pairs encoded and sorted by key, then value.
+EOF
+;;
+output) cat <<'EOF'
+Output files
+  .log.dat              every answer shown on the screen (404 only with --log-all-statuses);
+                        output-log.txt on Windows (Git Bash, MSYS2, Cygwin)
+  output-200.txt        the "200 OK" answers
+  output-ex404.txt      every answer that is not a "404 Not Found"
+  output-results.jsonl  every request as a JSON line (host, address, method, path, status, size..)
+  output-verify.txt     "verify output-results.jsonl": the hits still present, fixed or changed
+  output-defectdojo.json  --defectdojo: the hits as DefectDojo Generic Findings Import JSON
+  output-config.txt     the seed, config hash and command line reproducing the scan, credentials redacted
+  output-coverage.txt   the dictionary entries tried against every host
+  output-skipped.txt    every skipped request with its reason
+  output-retry.jsonl    the entries dropped and not recovered by --backfill, a jsonl dictionary
+  output-correlation.csv every request as the target logs it (time, source address, URL, user agent,
+                        status) with the scan id, for the defenders to find the scan in their logs
+  output-matrix.csv     hosts x interesting paths (multiple hosts)
+  output-vhostdiff.txt  --vhost-diff, output-deep.txt --deep, output-fleet.txt --fleet-dedup
+  output-sourcemaps.txt the original sources listed by the source maps found (--analyzer sourcemaps)
+  output-active.txt     --active-checks: the hits vulnerable to the active checks (host header injection,
+                        open redirect candidates) and what reflects the canary
+  output-pairdiff.txt   --mode pair: the paths whose two answers differ, with both statuses and sizes
+  output-headerdiff.txt --header-diff: the hits whose cookies or notable headers differ from the root
+  output-disclosure.txt --disclosure: the answers leaking internal IPs, hostnames, paths or traces
+  output-errorpages.txt --error-pages: the malformed requests, their status and the fingerprints found
+  crash-SCANID.txt      when a host job or the scan dies on an unexpected error: the failing command and
+                        its errors, host, entry, last answer and configuration, credentials redacted (the
+                        scan goes on with the other hosts, and a dead scan keeps its partial outputs)
+  output-profile.txt    the time spent in every module: the requests, each analyzer, the deep stage and the
+                        sinks (results, issues, defectdojo, matrix, top, fleet), also printed at the end
+  output-timings.txt    --timings: the average and slowest DNS, connect, TLS and server time per host
+  output-top.txt        the --top most interesting findings, with their score and its reasons
+  output-analyzers.txt  --analyzer: every tag, finding and follow-up request of the analyzers
+  output-digest.md      "digest": the hits new, fixed and changed since the last digest, and the trend
+                        (output-digest.html with --format html); digest-history.txt keeps the counts
+EOF
+;;
+*) echo -ne "Unknown help topic: $1\n"; help_topic ;;
+esac
+}
+
+## list_names profiles|wordlists|analyzers - prints the names of the available profiles, dictionaries or analyzers ##
+list_names() {
+case "$1" in
+profiles) ls ./profiles "$script_dir/profiles" "$HOME/.gHybridWebSearch/profiles" 2>/dev/null | grep '\.yml$' | sed 's/\.yml$//' | sort -u ;;
+wordlists) ls ./*.dic "$script_dir"/*.dic 2>/dev/null | sort -u ;;
+analyzers) { echo "$builtin_analyzers" | tr ' ' '\n'; find ./analyzers "$script_dir/analyzers" "$HOME/.gHybridWebSearch/analyzers" -maxdepth 1 -type f -perm -u+x -printf '%f\n' 2>/dev/null; } | sort -u ;;
+*) echo -ne "Usage: ./${0##*/} list profiles|wordlists|analyzers\n" ;;
+esac
+}
+
+## completion bash|zsh|fish - prints a completion script; profile and wordlist names are completed ##
+## at completion time by calling "list", so they follow the installed profiles                     ##
+completion() {
+local options=`usage | grep -o -- '--[a-z0-9-]*' | sort -u | tr '\n' ' '`
+local files="--hosts|--user-agent-file|--exclude-hosts|--import-burp|--import-zap|--rules-key|--body-file|--cookie-jar"
+local topics="dictionaries hosts vhost head deep analyzers profiles rules signing login states output"
+case "$1" in
+bash) cat <<EOF
+_gHybridWebSearch() {
+local cur=\${COMP_WORDS[COMP_CWORD]} prev=\${COMP_WORDS[COMP_CWORD-1]}
+case "\$prev" in
+--profile) COMPREPLY=(\$(compgen -W "\$(\${COMP_WORDS[0]} list profiles)" -- "\$cur") \$(compgen -f -- "\$cur")); return ;;
+-d|--dic) COMPREPLY=(\$(compgen -W "\$(\${COMP_WORDS[0]} list wordlists)" -- "\$cur") \$(compgen -f -- "\$cur")); return ;;
+help) COMPREPLY=(\$(compgen -W "$topics" -- "\$cur")); return ;;
+list) COMPREPLY=(\$(compgen -W "profiles wordlists analyzers" -- "\$cur")); return ;;
+--analyzer) COMPREPLY=(\$(compgen -W "\$(\${COMP_WORDS[0]} list analyzers)" -- "\$cur")); return ;;
+completion) COMPREPLY=(\$(compgen -W "bash zsh fish" -- "\$cur")); return ;;
+rules) COMPREPLY=(\$(compgen -W "update" -- "\$cur")); return ;;
+wordlist) COMPREPLY=(\$(compgen -W "fetch" -- "\$cur")); return ;;
+state) COMPREPLY=(\$(compgen -W "set list" -- "\$cur")); return ;;
+$files) COMPREPLY=(\$(compgen -f -- "\$cur")); return ;;
+esac
+if [ \$COMP_CWORD -eq 1 ] && [[ "\$cur" != -* ]]; then
+COMPREPLY=(\$(compgen -W "$commands" -- "\$cur"))
+return
+fi
+COMPREPLY=(\$(compgen -W "$options" -- "\$cur"))
+}
+complete -o default -F _gHybridWebSearch gHybridWebSearch.sh ${0##*/}
+EOF
+;;
+zsh) cat <<EOF
+#compdef gHybridWebSearch.sh
+_gHybridWebSearch() {
+case "\${words[CURRENT-1]}" in
+--profile) compadd -- \$(\${words[1]} list profiles); _files; return ;;
+-d|--dic) compadd -- \$(\${words[1]} list wordlists); _files; return ;;
+help) compadd $topics; return ;;
+list) compadd profiles wordlists analyzers; return ;;
+--analyzer) compadd -- \$(\${words[1]} list analyzers); return ;;
+completion) compadd bash zsh fish; return ;;
+rules) compadd update; return ;;
+wordlist) compadd fetch; return ;;
+state) compadd set list; return ;;
+$files) _files; return ;;
+esac
+if [[ "\${words[CURRENT]}" == -* ]]; then
+compadd -- $options
+elif (( CURRENT == 2 )); then
+compadd $commands
+fi
+}
+compdef _gHybridWebSearch gHybridWebSearch.sh ${0##*/}
+EOF
+;;
+fish) cat <<EOF
+complete -c gHybridWebSearch.sh -n '__fish_use_subcommand' -a '$commands'
+complete -c gHybridWebSearch.sh -n '__fish_seen_subcommand_from help' -a '$topics'
+complete -c gHybridWebSearch.sh -n '__fish_seen_subcommand_from list' -a 'profiles wordlists analyzers'
+complete -c gHybridWebSearch.sh -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'
+complete -c gHybridWebSearch.sh -n '__fish_seen_subcommand_from rules' -a 'update'
+complete -c gHybridWebSearch.sh -n '__fish_seen_subcommand_from wordlist' -a 'fetch'
+complete -c gHybridWebSearch.sh -n '__fish_seen_subcommand_from state' -a 'set list'
+complete -c gHybridWebSearch.sh -l profile -x -a '(gHybridWebSearch.sh list profiles)'
+complete -c gHybridWebSearch.sh -s d -l dic -r -a '(gHybridWebSearch.sh list wordlists)'
+complete -c gHybridWebSearch.sh -l analyzer -x -a '(gHybridWebSearch.sh list analyzers)'
+EOF
+for option in $options; do
+echo "complete -c gHybridWebSearch.sh -l ${option#--}"
+done
+;;
+*) echo -ne "Usage: ./${0##*/} completion bash|zsh|fish\n" ;;
+esac
+}
+
+## capabilities [--json] - describes the commands, modes, formats and options of this version ##
+capabilities() {
+local modes="single-host hosts cidr path head vhost pair vhost-diff fleet-dedup deep"
+local formats="plain csv jsonl burp zap"
+local outputs="$log_file output-200.txt output-ex404.txt output-results.jsonl output-defectdojo.json output-verify.txt output-config.txt output-coverage.txt output-skipped.txt output-matrix.csv output-vhostdiff.txt output-pairdiff.txt output-headerdiff.txt output-disclosure.txt output-errorpages.txt output-deep.txt output-fleet.txt output-timings.txt output-top.txt output-analyzers.txt output-retry.jsonl output-correlation.csv output-active.txt output-sourcemaps.txt output-profile.txt"
+local flags=`usage | awk '/^  -/ { if (line != "") print line; line=$0; next } /^     / && line != "" { sub(/^ +/, " "); line=line $0 } END { print line }' | sed -n 's/^  \(-[a-zA-Z], \)\{0,1\}\(--[a-z0-9-]*\)\( [^ ]*\)\{0,1\}  *\(.*\)$/\2\t\3\t\4/p'`
+if [ "$1" != "--json" ]; then
+echo -ne "gHybridWebSearch $version\ncommands: $commands\nmodes: $modes\ndictionary formats: $formats\noutputs: $outputs\noptions:\n"
+echo "$flags" | awk -F'\t' '{ printf "  %-22s %s\n", $1 $2, $3 }'
+return
+fi
+echo "$flags" | awk -F'\t' -v version="$version" -v commands="$commands" -v modes="$modes" -v formats="$formats" -v outputs="$outputs" '
+function str(v) { gsub(/\\/, "\\\\\\\\", v); gsub(/"/, "\\\"", v); return "\"" v "\"" }
+function list(v,   n, i, a, out) { n=split(v, a, " "); for (i = 1; i <= n; i++) out=out (i > 1 ? ", " : "") str(a[i]); return "[" out "]" }
+{ sub(/^ /, "", $2); option[NR]="    {\"name\": " str($1) ", \"argument\": " ($2 == "" ? "null" : str($2)) ", \"description\": " str($3) "}" }
+END {
+printf "{\n  \"name\": \"gHybridWebSearch\",\n  \"version\": %s,\n", str(version)
+printf "  \"commands\": %s,\n  \"modes\": %s,\n  \"dictionary_formats\": %s,\n  \"outputs\": %s,\n", list(commands), list(modes), list(formats), list(outputs)
+printf "  \"options\": [\n"; for (i = 1; i <= NR; i++) printf "%s%s\n", option[i], (i < NR ? "," : ""); printf "  ]\n}\n"
+}'
+}
+
+## find_profile NAME - prints the path of a profile given by name or file ##
+find_profile() {
+local dir
+if [ -f "$1" ]; then
+echo "$1"
+return
+fi
+for dir in "./profiles" "${0%/*}/profiles" "$HOME/.gHybridWebSearch/profiles"; do
+if [ -f "$dir/$1.yml" ]; then
+echo "$dir/$1.yml"
+return
+fi
+done
+}
+
+## find_analyzer NAME - prints the path of the executable analyzer NAME of ./analyzers, the script ##
+## directory or ~/.gHybridWebSearch/analyzers                                                     ##
+find_analyzer() {
+local dir
+for dir in ./analyzers "$script_dir/analyzers" "$HOME/.gHybridWebSearch/analyzers"; do
+if [ -f "$dir/$1" ] && [ -x "$dir/$1" ]; then
+echo "$dir/$1"
+return
+fi
+done
+}
+
+## success_filter EXPRESSION - compiles a --success-expr into a bash [[ ]] condition, printed first, then the ##
+## text and regex values it refers to as ${success_parts[N]}, one per line                                 ##
+success_filter() {
+echo "$1" | awk '
+function error(message) { print "Invalid --success-expr: " message > "/dev/stderr"; exit 1 }
+function next_token() {
+sub(/^[ \t]+/, "", text)
+if (text == "") { token=""; return }
+if (match(text, /^"([^"\\]|\\.)*"/) || match(text, /^(&&|\|\||==|!=|>=|<=|!~|[<>~!(),])/) || match(text, /^[0-9]+/) || match(text, /^[a-z_]+[0-9]*/)) {
+token=substr(text, 1, RLENGTH); text=substr(text, RLENGTH + 1); return }
+error("unexpected " substr(text, 1, 10))
+}
+function operand(name) { if (name ~ /^baseline[0-9][0-9][0-9]$/) return "${baselines[" substr(name, 9) "]:-0}"
+if (!(name in fields)) error("unknown field " name); return fields[name] }
+function literal(value) { value=substr(value, 2, length(value) - 2); gsub(/\\"/, "\"", value); parts[++count]=value; return count }
+function expr(   left) { left=term(); while (token == "&&" || token == "||") { op=token; next_token(); left="( " left " " op " " term() " )" } return left }
+function term(   inner, field, op, value, list) {
+if (token == "!") { next_token(); return "! " term() }
+if (token == "(") { next_token(); inner=expr(); if (token != ")") error("missing )"); next_token(); return "( " inner " )" }
+if (token !~ /^[a-z_]+[0-9]*$/) error("field expected, got " (token == "" ? "the end" : token))
+field=operand(token == "status" ? "code" : token == "size" ? "length" : token); numeric=(token ~ /^(code|status|length|size|baseline[0-9]*)$/)
+next_token(); op=token
+if (op == "in") { next_token(); if (token != "(") error("( expected after in"); list=""
+do { next_token(); if (token !~ /^[0-9]+$/) error("number expected in the list of in"); list=list (list == "" ? "" : " || ") field " -eq " token; next_token() } while (token == ",")
+if (token != ")") error("missing ) after the list of in"); next_token(); return "( " list " )" }
+if (op !~ /^(==|!=|>=|<=|>|<|~|!~)$/) error("operator expected after " field)
+next_token(); value=token
+if (value ~ /^"/) { value="${success_parts[" literal(value) "]}"; next_token(); if (op == "~") return "\"" field "\" =~ " value; if (op == "!~") return "! \"" field "\" =~ " value
+if (op != "==" && op != "!=") error(op " compares numbers"); return "\"" field "\" " op " \"" value "\"" }
+if (value ~ /^[a-z_]+[0-9]*$/) value=operand(value == "status" ? "code" : value == "size" ? "length" : value)
+else if (value !~ /^[0-9]+$/) error("value expected after " op)
+if (!numeric) error(op " " value " compares a text field with a number")
+next_token()
+return field " " num[op] " " value
+}
+{ fields["code"]="${result_code:-0}"; fields["length"]="${result_length:-0}"; fields["baseline"]="${baseline_length:-0}"; fields["path"]="$result_path"; fields["method"]="$method"; fields["title"]="$result_title"; fields["type"]="$result_type"
+num["=="]="-eq"; num["!="]="-ne"; num[">"]="-gt"; num["<"]="-lt"; num[">="]="-ge"; num["<="]="-le"
+text=$0; next_token(); condition=expr()
+if (token != "") error("unexpected " token)
+print condition; for (i = 1; i <= count; i++) print parts[i] }'
+}
+
+## load_profile FILE - turns a profile (flat YAML of option: value and option: [list], or a pipeline: ##
+## of seed, mutate, probe, analyze and sink stages) into arguments, one per line; a line starting    ##
+## with ! is the error of an invalid pipeline                                                       ##
+load_profile() {
+awk -v dir="${1%/*}" '
+BEGIN { n=split("seed mutate probe analyze sink", order, " "); for (i = 1; i <= n; i++) rank[order[i]]=i
+options["seed"]="dic dic-format dic-sha256 seclists-rev fetch-allow path hosts exclude-hosts import-burp import-zap"
+options["mutate"]="extensions variants url-encoding shuffle seed dedup dedup-dir low-memory mode vhost-path pair-with"
+options["probe"]="method header user-agent user-agent-file user-agent-rotation spoof-ip spoof-headers auth token ntlm digest cookie cookie-jar login-url login-data login-success-regex login-csrf-regex logged-out body body-file content-type scheme port insecure sni ca-cert client-cert client-key tls-min-version proxy proxy-file tor tor-socks tor-control tor-newnym resolve source-ip rotate-source unix-socket prefer-ipv4 prefer-ipv6 resolver doh dns-ttl pin-dns http-version http2 http3 connect-timeout response-header-timeout request-timeout transport no-preflight timings timestamps follow-redirects max-redirects head no-escalate jitter pause-on-5xx 5xx-window cooldown no-resume no-adaptive max-backoff max-failures down-wait backfill rate max-connects max-bandwidth threads hmac-key hmac-header signer aws-sigv4"
+options["analyze"]="success-expr active-checks vhost-diff fleet-dedup header-diff allowed-methods disclosure entropy entropy-min error-pages detect-language language analyzer sourcemap-dir analyzer-budget follow-up-depth deep deep-trigger deep-threads deep-command"
+options["sink"]="top log-all-statuses keep-partial state-file defectdojo defectdojo-url defectdojo-engagement defectdojo-token create-issues github-repo jira-url jira-project" }
+function unquote(v) { sub(/[ \t]+#.*$/, "", v); gsub(/^[ \t\r]+|[ \t\r]+$/, "", v); gsub(/^["\047]|["\047]$/, "", v); return v }
+function emit(k, v) { if (v == "false") return; print "--" k; if (v == "true") return; if (k == "dic" && v !~ /^\/|^[A-Za-z]+:\/\/|^seclists:/) v=dir "/" v; print v }
+function fail(m) { print "!Pipeline of " FILENAME ", line " FNR ": " m; exit 1 }
+function indent() { match($0, /^[ \t]*/); return RLENGTH }
+function begin_stage(header) { kind=header; sub(/[ \t].*$/, "", kind); name=substr(header, length(kind) + 1); gsub(/^[ \t]+/, "", name); stage=kind " stage" (name != "" ? " (" name ")" : "")
+if (!(kind in rank)) fail("unknown stage " kind " (seed, mutate, probe, analyze or sink)")
+if (rank[kind] < last) fail("the " stage " cannot follow the " order[last] " stage, the order is seed, mutate, probe, analyze, sink")
+last=rank[kind]; stage_list="" }
+function stage_option(k, v) { if (index(" " options[kind] " ", " " k " ") == 0) fail("--" k " is not an option of the " stage)
+if (v ~ /^\[.*\]$/) { gsub(/^\[|\]$/, "", v); m=split(v, items, ","); for (i = 1; i <= m; i++) emit(k, unquote(items[i])); return }
+emit(k, v == "" ? "true" : v) }
+/^[ \t]*(#|$)/ { next }
+list == "pipeline" && kind != "" && stage_list != "" && /^[ \t]*- / && indent() > stage_indent { item=$0; sub(/^[ \t]*- [ \t]*/, "", item); stage_option(stage_list, unquote(item)); next }
+list == "pipeline" && /^[ \t]*- / { stage_indent=indent(); item=$0; sub(/^[ \t]*- [ \t]*/, "", item); header=unquote(item); sub(/:.*$/, "", header); value=item; if (!sub(/^[^:]*:[ \t]*/, "", value)) fail("expected \"- stage: option\" or \"- stage:\" and its options")
+begin_stage(header); value=unquote(value)
+if (value != "") { k=value; sub(/[ \t].*$/, "", k); v=substr(value, length(k) + 1); gsub(/^[ \t]+/, "", v); stage_option(k, v) }
+next }
+list == "pipeline" && kind != "" && /^[ \t]+[A-Za-z0-9_-]+:/ && indent() > stage_indent { k=$0; sub(/:.*$/, "", k); gsub(/[ \t]/, "", k); v=$0; sub(/^[^:]*:[ \t]*/, "", v); v=unquote(v)
+if (k == "name") next
+if (v == "") { stage_list=k; if (index(" " options[kind] " ", " " k " ") == 0) fail("--" k " is not an option of the " stage); next }
+stage_list=""; stage_option(k, v); next }
+/^[ \t]*- / { item=$0; sub(/^[ \t]*- [ \t]*/, "", item); emit(list, unquote(item)); next }
+/^[A-Za-z0-9_-]+:/ { key=$0; sub(/:.*$/, "", key); value=$0; sub(/^[^:]*:[ \t]*/, "", value); value=unquote(value); kind=""
+if (key == "name" || key == "description") next
+if (value == "") { list=key; next }
+list=""
+if (value ~ /^\[.*\]$/) { gsub(/^\[|\]$/, "", value); n=split(value, items, ","); for (i = 1; i <= n; i++) emit(key, unquote(items[i])); next }
+emit(key, value) }
+' "$1"
+}
+
+command=""
+case "$1" in
+rules) command="rules $2"; shift 2 ;;
+wordlist) command="wordlist $2"; dic=$3; shift 3 ;;
+verify) command=verify; verify_file=$2; shift 2 ;;
+query) command=query; query_args=("${@:2}"); set -- ;;
+digest) command=digest; digest_args=("${@:2}"); set -- ;;
+state) command=state; state_args=("${@:2}"); set -- ;;
+help) help_topic "$2"; exit ;;
+list) list_names "$2"; exit ;;
+completion) completion "$2"; exit ;;
+capabilities) capabilities "$2"; exit ;;
+esac
+
+for (( i = 1; i <= $#; i++ )); do
+if [ "${!i}" == "--profile" ]; then
+j=$(( i + 1 ))
+profile=`find_profile "${!j}"`
+if [ "$profile" == "" ]; then
+echo -ne "Profile not found: ${!j}\n"
 exit
 fi
+mapfile -t profile_args < <(load_profile "$profile")
+if [[ "${profile_args[-1]}" == "!"* ]]; then
+echo -ne "${profile_args[-1]#!}\n"
+exit 1
+fi
+set -- "${profile_args[@]}" "$@"
+break
+fi
+done
+
+config=("$@")
+while [ "$#" -gt 0 ]; do
+case "$1" in
+--profile) shift ;;
+-s|--scheme) scheme=${2,,}; scheme_given=1; shift ;;
+--no-preflight) preflight=0 ;;
+--dns-ttl) dns_ttl=$2; shift ;;
+--pin-dns) pin_dns=1 ;;
+-p|--port) port_option=$2; shift ;;
+-k|--insecure) insecure=1 ;;
+--sni) sni=$2; shift ;;
+--ca-cert) tls_options+=(-CAfile "$2"); curl_options+=(--cacert "$2"); shift ;;
+--client-cert) tls_options+=(-cert "$2"); curl_options+=(--cert "$2"); shift ;;
+--client-key) tls_options+=(-key "$2"); curl_options+=(--key "$2"); shift ;;
+--http-version) http_version=$2; shift ;;
+--http2) http_version=2 ;;
+--connect-timeout) connect_timeout=$2; shift ;;
+--response-header-timeout) header_timeout=$2; shift ;;
+--request-timeout) request_timeout=$2; shift ;;
+--transport) transport=$2; shift ;;
+--timings) timings=1 ;;
+--timestamps) timestamps=1 ;;
+--follow-redirects) follow_redirects=1 ;;
+--max-redirects) max_redirects=$2; follow_redirects=1; shift ;;
+--http3) http_version=3 ;;
+--proxy) proxy=$2; shift ;;
+--proxy-file) proxy_file=$2; shift ;;
+--tor) tor=1 ;;
+--tor-socks) tor=1; tor_socks=$2; shift ;;
+--tor-control) tor_control=$2; shift ;;
+--tor-newnym) tor=1; tor_newnym=$2; shift ;;
+--resolve) if [[ "$2" =~ ^([^:]+):(([0-9]+):)?(\[([0-9a-fA-F.:]*:[0-9a-fA-F.:]*)\]|([0-9]+\.[0-9]+\.[0-9]+\.[0-9]+))$ ]]; then
+resolves="$resolves${BASH_REMATCH[1],,}\t${BASH_REMATCH[3]}\t${BASH_REMATCH[5]}${BASH_REMATCH[6]}\n"
+else
+echo -ne "Invalid --resolve $2 (host:ip or host:port:ip, an IPv6 address in brackets: host:[2001:db8::1])\n"
+exit 1
+fi
+shift ;;
+--source-ip) source_ips+=("$2"); shift ;;
+--rotate-source) rotate_source=1 ;;
+--unix-socket) unix_socket=$2; shift ;;
+--prefer-ipv4) prefer_family=4 ;;
+--prefer-ipv6) prefer_family=6 ;;
+--resolver) resolver=$2; shift ;;
+--doh) doh=$2; shift ;;
+--tls-min-version) tls_min_version=$2; shift ;;
+--seed) seed=$2; shift ;;
+--shuffle) shuffle=1 ;;
+--dedup) dedup=1 ;;
+--dedup-dir) dedup=1; dedup_dir=$2; shift ;;
+--low-memory) low_memory=1 ;;
+--jitter) jitter=$2; shift ;;
+--rate) rate=$2; shift ;;
+--max-connects) max_connects=$2; shift ;;
+--max-bandwidth) max_bandwidth=${2,,}; shift ;;
+--pause-on-5xx) storm_threshold=$2; shift ;;
+--5xx-window) storm_window=$2; shift ;;
+--cooldown) cooldown=$2; shift ;;
+--no-resume) storm_resume=0 ;;
+--no-adaptive) adaptive=0 ;;
+--max-backoff) max_backoff=$2; shift ;;
+--backfill) backfill_rounds=$2; shift ;;
+--max-failures) max_failures=$2; shift ;;
+--down-wait) down_wait=$2; shift ;;
+--rules-url) rules_url=$2; shift ;;
+--rules-key) rules_key=$2; shift ;;
+--fetch-allow) fetch_allow="$fetch_allow${fetch_allow:+,}$2"; shift ;;
+--state-file) state_file=$2; shift ;;
+--keep-partial) keep_partial=1 ;;
+--defectdojo) defectdojo=1 ;;
+--create-issues) create_issues=$2; shift ;;
+--github-repo) github_repo=$2; shift ;;
+--jira-url) jira_url=$2; shift ;;
+--jira-project) jira_project=$2; shift ;;
+--defectdojo-url) defectdojo=1; defectdojo_url=$2; shift ;;
+--defectdojo-token) defectdojo_token=$2; shift ;;
+--defectdojo-engagement) defectdojo_engagement=$2; shift ;;
+-d|--dic) dic=$2; shift ;;
+-x|--extensions) extensions="$extensions$2,"; shift ;;
+--dic-format) dic_format=$2; shift ;;
+--url-encoding) url_encoding=$2; shift ;;
+--variants) variants=$2; shift ;;
+--dic-sha256) dic_sha256=$2; shift ;;
+--seclists-rev) seclists_rev=$2; shift ;;
+--import-burp) dic=$2; dic_format=burp; shift ;;
+--import-zap) dic=$2; dic_format=zap; shift ;;
+--hosts) hosts=$2; shift ;;
+--path) paths="$paths$2,"; dic_format=paths; threads=${threads:-20}; shift ;;
+-t|--threads) threads=$2; shift ;;
+--exclude-hosts) exclude_hosts=$2; shift ;;
+--vhost-diff) vhost_diff=1 ;;
+--active-checks) active_checks="$active_checks${active_checks:+,}$2"; shift ;;
+--top) top=$2; shift ;;
+--mode) mode=$2; shift ;;
+--vhost-path) vhost_path=/${2#/}; shift ;;
+--pair-with) pair_with=$2; shift ;;
+--log-all-statuses) log_all=1 ;;
+--success-expr) success_expr=$2; shift ;;
+--head) default_method=HEAD ;;
+-X|--method) default_method=${2^^}; shift ;;
+-H|--header) custom_headers+=("$2"); shift ;;
+-A|--user-agent) user_agents=("$2"); shift ;;
+--user-agent-file) user_agent_file=$2; shift ;;
+--user-agent-rotation) agent_rotation=$2; shift ;;
+--spoof-ip) spoof_ip=$2; shift ;;
+--spoof-headers) spoof_headers=$2; shift ;;
+--auth) auth=$2; shift ;;
+--ntlm) ntlm=$2; shift ;;
+--digest) digest=$2; shift ;;
+--token) token=$2; shift ;;
+--cookie) cookies="$cookies${cookies:+; }$2"; shift ;;
+--cookie-jar) cookie_jar=$2; shift ;;
+--login-url) login_url=$2; shift ;;
+--login-data) login_data=$2; shift ;;
+--login-success-regex) login_success_regex=$2; shift ;;
+--login-csrf-regex) login_csrf_regex=$2; shift ;;
+--logged-out) logged_out_regex=$2; shift ;;
+--body) default_body=$2; shift ;;
+--body-file) body_file=$2; shift ;;
+--content-type) content_type=$2; shift ;;
+--no-escalate) escalate=0 ;;
+--fleet-dedup) fleet_dedup=1 ;;
+--header-diff) header_diff=1 ;;
+--allowed-methods) check_methods=1 ;;
+--disclosure) check_disclosure=1 ;;
+--entropy) check_entropy=1 ;;
+--entropy-min) entropy_min=$2; shift ;;
+--error-pages) error_mining=1 ;;
+--detect-language) detect_language=1 ;;
+--language) detect_language=1; languages=${2,,}; shift ;;
+--sourcemap-dir) sourcemap_dir=$2; shift ;;
+--analyzer-budget) analyzer_budget=$2; shift ;;
+--follow-up-depth) follow_up_depth=$2; shift ;;
+--analyzer) analyzers+=("$2"); shift ;;
+--deep) deep_trigger=${deep_trigger:-^(200|401|403)$} ;;
+--deep-trigger) deep_trigger=$2; shift ;;
+--deep-threads) deep_threads=$2; shift ;;
+--deep-command) deep_command=$2; shift ;;
+--hmac-key) hmac_key=$2; shift ;;
+--hmac-header) hmac_header=$2; shift ;;
+--signer) signer=$2; shift ;;
+--aws-sigv4) aws_sigv4=$2; shift ;;
+-h|--help) usage; exit ;;
+-*) echo -ne "Unknown option: $1\n"; usage; exit ;;
+*) server=$1 ;;
+esac
+shift
+done
+
+if [[ "$dic" == seclists:* ]]; then
+dic="$seclists_url/$seclists_rev/${dic#seclists:}"
+fi
+if [ "$command" == "rules update" ]; then
+update_rules
+exit
+elif [ "$command" == "wordlist fetch" ]; then
+if ! [[ "$dic" =~ ^[A-Za-z]+:// ]]; then
+echo -ne "A SecLists path (seclists:Discovery/Web-Content/common.txt) or a wordlist URL is needed.\n"
+exit 1
+fi
+fetch_wordlist
+echo -ne "Cached in $dic\n"
+exit
+elif [ "$command" != "" ] && [ "$command" != "verify" ] && [ "$command" != "state" ] && [ "$command" != "query" ] && [ "$command" != "digest" ]; then
+echo -ne "Unknown command: $command\n"
+usage
+exit 1
+fi
+
+if [ "$command" == "" ] && [ "$server" == "" ] && [ "$hosts" == "" ]; then
+echo -ne "You need to pass a URL as an argument to work.\n"
+usage
+exit
+fi
+threads=${threads:-1}
+if [ "$low_memory" == "1" ]; then
+sort_buffer=4M
+body_limit=262144
+deep_threads=1
+if [ "$threads" -gt 4 ]; then
+echo -ne "--low-memory: running 4 hosts at a time instead of $threads\n"
+threads=4
+fi
+fi
+default_scheme=$scheme
+if [ "$create_issues" != "" ] && [ "$create_issues" != "jira" ] && [ "$create_issues" != "github" ]; then
+echo -ne "Unknown issue tracker: $create_issues (jira or github)\n"
+exit 1
+fi
+case "$tls_min_version" in
+""|1.0) ;;
+1.1) tls_options+=(-no_ssl3 -no_tls1); curl_options+=(--tlsv1.1) ;;
+1.2) tls_options+=(-no_ssl3 -no_tls1 -no_tls1_1); curl_options+=(--tlsv1.2) ;;
+1.3) tls_options+=(-no_ssl3 -no_tls1 -no_tls1_1 -no_tls1_2); curl_options+=(--tlsv1.3) ;;
+*) echo -ne "Unknown TLS version: $tls_min_version (1.0, 1.1, 1.2 or 1.3)\n"; exit 1 ;;
+esac
+if [ "$scheme" != "http" ] && [ "$scheme" != "https" ]; then
+echo -ne "Unknown scheme: $scheme\n"
+exit 1
+fi
+if [ "$mode" != "path" ] && [ "$mode" != "vhost" ] && [ "$mode" != "pair" ]; then
+echo -ne "Unknown mode: $mode (path, vhost or pair)\n"
+exit 1
+fi
+if ! [[ "$pair_with" =~ ^(slash|scheme(:[0-9]+)?|header:[^:]+:.*|method:[A-Z][A-Z0-9_-]*)$ ]]; then
+echo -ne "Invalid --pair-with: $pair_with (slash, scheme[:port], header:Name: value or method:NAME)\n"
+exit 1
+fi
+if [ "$exclude_hosts" != "" ]; then
+if ! [ -r "$exclude_hosts" ]; then
+echo -ne "Cannot read --exclude-hosts: $exclude_hosts\n"
+exit 1
+fi
+cidr=`tr -d '\r' < "$exclude_hosts" | sed 's/#.*$//; s/[ \t]*//g' | grep '/' | grep -v -E '^[0-9]+(\.[0-9]+){3}/([0-9]|[12][0-9]|3[0-2])$|^[0-9a-fA-F]*:[0-9a-fA-F:.]*/([0-9]|[1-9][0-9]|1[01][0-9]|12[0-8])$' | head -1`
+if [ "$cidr" != "" ]; then
+echo -ne "Invalid CIDR in --exclude-hosts: $cidr (IPv4/0-32 or IPv6/0-128)\n"
+exit 1
+fi
+fi
+if ! [[ "$follow_up_depth" =~ ^[0-9]+$ ]]; then
+echo -ne "Invalid --follow-up-depth: $follow_up_depth (levels)\n"
+exit 1
+fi
+if [ "$analyzer_budget" != "" ] && ! [[ "$analyzer_budget" =~ ^[1-9][0-9]*$ ]]; then
+echo -ne "Invalid --analyzer-budget: $analyzer_budget (seconds)\n"
+exit 1
+fi
+if [ "$rate" != "" ] && ! { [[ "$rate" =~ ^([0-9]+\.?[0-9]*|\.[0-9]+)$ ]] && [[ "$rate" =~ [1-9] ]]; }; then
+echo -ne "Invalid --rate: $rate (requests per second, more than 0, e.g. 5 or 0.5)\n"
+exit 1
+fi
+if [ "$max_connects" != "" ] && ! { [[ "$max_connects" =~ ^([0-9]+\.?[0-9]*|\.[0-9]+)$ ]] && [[ "$max_connects" =~ [1-9] ]]; }; then
+echo -ne "Invalid --max-connects: $max_connects (connections per second, more than 0)\n"
+exit 1
+fi
+if ! [[ "$default_method" =~ ^[A-Z][A-Z0-9_-]*$ ]]; then
+echo -ne "Invalid method: $default_method\n"
+exit 1
+fi
+if [ "$auth" != "" ] && [ "$token" != "" ]; then
+echo -ne "--auth and --token cannot be used together\n"
+exit 1
+elif [ "$ntlm" != "" ] && [ "$auth$token" != "" ]; then
+echo -ne "--ntlm cannot be used with --auth or --token\n"
+exit 1
+elif [ "$digest" != "" ] && [ "$auth$token$ntlm" != "" ]; then
+echo -ne "--digest cannot be used with --auth, --token or --ntlm\n"
+exit 1
+elif [ "$digest" != "" ] && [[ "$digest" != *:* ]]; then
+echo -ne "Invalid --digest: user:password expected\n"
+exit 1
+elif [ "$ntlm" != "" ] && { [[ "$ntlm" != *:* ]] || [ "$http_version" == "1.0" ]; }; then
+echo -ne "Invalid --ntlm: DOMAIN\\user:password expected, over HTTP/1.1\n"
+exit 1
+elif [ "$ntlm" != "" ] && ! curl --version 2>/dev/null | grep -q '^Features:.* NTLM'; then
+echo -ne "--ntlm needs a curl built with NTLM support (see curl --version)\n"
+exit 1
+elif [ "$auth" != "" ] && [[ "$auth" != *:* ]]; then
+echo -ne "Invalid --auth: user:password expected\n"
+exit 1
+elif [ "$aws_sigv4" != "" ] && [ "$auth$token$ntlm$digest" != "" ]; then
+echo -ne "--aws-sigv4 cannot be used with --auth, --token, --ntlm or --digest\n"
+exit 1
+elif [ "$aws_sigv4" != "" ] && ! [[ "$aws_sigv4" =~ ^[a-z0-9-]+/[a-z0-9-]+$ ]]; then
+echo -ne "Invalid --aws-sigv4: $aws_sigv4 (region/service, e.g. eu-west-1/execute-api)\n"
+exit 1
+elif [ "$aws_sigv4" != "" ] && [ "$AWS_ACCESS_KEY_ID" == "" -o "$AWS_SECRET_ACCESS_KEY" == "" ]; then
+echo -ne "--aws-sigv4 needs the credentials in \$AWS_ACCESS_KEY_ID and \$AWS_SECRET_ACCESS_KEY\n"
+exit 1
+elif [ "$auth" != "" ]; then
+authorization="Basic `echo -n "$auth" | base64 -w 0`"
+elif [ "$token" != "" ]; then
+authorization="Bearer $token"
+fi
+if [[ "$login_url" == *://* ]]; then
+login_url=${login_url#*://*/}
+fi
+if [ "$login_url" != "" ]; then
+login_url=/${login_url#/}
+fi
+for check in ${active_checks//,/ }; do
+if [ "$check" != "host-header" ] && [ "$check" != "open-redirect" ]; then
+echo -ne "Unknown active check: $check (host-header or open-redirect)\n"
+exit 1
+fi
+done
+for header in "${custom_headers[@]}"; do
+if ! [[ "$header" =~ ^[A-Za-z0-9!#$%\&\'*+.^_\`~-]+:\ *[^\ ] ]] || [[ "$header" == *[$'\r\n']* ]]; then
+echo -ne "Invalid header: $header (Name: value)\n"
+exit 1
+fi
+done
+if [ "$user_agent_file" != "" ]; then
+if ! [ -r "$user_agent_file" ]; then
+echo -ne "User-Agent file not found: $user_agent_file\n"
+exit 1
+fi
+mapfile -t user_agents < <(tr -d '\r' < "$user_agent_file" | grep -v -E '^[[:space:]]*(#|$)')
+if [ "${#user_agents[@]}" -eq 0 ]; then
+echo -ne "No User-Agent in $user_agent_file\n"
+exit 1
+fi
+fi
+if [ "$spoof_ip" != "" ] && ! [[ "$spoof_ip" =~ ^(random|([0-9]{1,3}\.){3}[0-9]{1,3}(/([89]|[12][0-9]|3[0-2]))?|[0-9a-fA-F:]*:[0-9a-fA-F:]*)$ ]]; then
+echo -ne "Invalid --spoof-ip: $spoof_ip (random, an address or an IPv4 network, e.g. 10.0.0.0/8)\n"
+exit 1
+fi
+if ! [[ "$spoof_headers" =~ ^[A-Za-z0-9-]+(,[A-Za-z0-9-]+)*$ ]]; then
+echo -ne "Invalid --spoof-headers: $spoof_headers (comma separated header names, e.g. X-Forwarded-For,X-Real-IP)\n"
+exit 1
+fi
+for variant in ${variants//,/ }; do
+if [ "$variant" != "slash" ] && [ "$variant" != "case" ]; then
+echo -ne "Unknown variant: $variant (slash or case)\n"
+exit 1
+fi
+done
+if [ "$url_encoding" != "raw" ] && [ "$url_encoding" != "path-escape" ] && [ "$url_encoding" != "full-escape" ]; then
+echo -ne "Unknown --url-encoding: $url_encoding (raw, path-escape or full-escape)\n"
+exit 1
+fi
+if [ "$agent_rotation" != "random" ] && [ "$agent_rotation" != "round-robin" ]; then
+echo -ne "Unknown --user-agent-rotation: $agent_rotation (random or round-robin)\n"
+exit 1
+fi
+if [ "$body_file" != "" ]; then
+if ! [ -r "$body_file" ]; then
+echo -ne "Body file not found: $body_file\n"
+exit 1
+fi
+if [ "`tr -d '\000' < "$body_file" | wc -c`" != "`wc -c < "$body_file"`" ]; then
+echo -ne "NUL bytes in $body_file: the bodies are text (JSON, XML, forms..), binary ones cannot be sent\n"
+exit 1
+fi
+IFS= read -r -d '' default_body < "$body_file"
+fi
+if [ "$default_body" != "" ] && [ "$content_type" == "" ]; then
+content_type="application/x-www-form-urlencoded"
+if [[ "$default_body" =~ ^[[:space:]]*[\{\[] ]]; then
+content_type="application/json"
+fi
+fi
+case "$max_bandwidth" in
+"") ;;
+*[0-9]k) max_bandwidth=$(( ${max_bandwidth%k} * 1024 )) ;;
+*[0-9]m) max_bandwidth=$(( ${max_bandwidth%m} * 1048576 )) ;;
+*[0-9]) ;;
+*) echo -ne "Unknown --max-bandwidth: $max_bandwidth (bytes per second, or with k or m)\n"
+exit 1 ;;
+esac
+if [ "$success_expr" != "" ]; then
+mapfile -t success_parts < <(success_filter "$success_expr" || echo "!")
+if [ "${success_parts[0]}" == "!" ]; then
+exit 1
+fi
+fi
+for name in "${analyzers[@]}"; do
+if [[ " $builtin_analyzers " != *" $name "* ]]; then
+analyzer_paths[$name]=`find_analyzer "$name"`
+if [ "${analyzer_paths[$name]}" == "" ]; then
+echo -ne "Analyzer not found: $name (built-in: $builtin_analyzers, or an executable of ./analyzers)\n"
+exit 1
+fi
+fi
+done
+if [ "$resolver" != "" ] && [[ "$resolver" != *:* ]]; then
+resolver="$resolver:53"
+fi
+if [ "$unix_socket" != "" ] && [ ! -S "$unix_socket" ]; then
+echo -ne "Not a Unix domain socket: $unix_socket\n"
+exit 1
+fi
+if [ "$unix_socket" != "" ] && { [ "$proxy" != "" ] || [ "$proxy_file" != "" ] || [ "$tor" == "1" ]; }; then
+echo -ne "--unix-socket cannot be used with --proxy, --proxy-file or --tor\n"
+exit 1
+fi
+case "$http_version" in
+""|1.0|1.1|2|3) ;;
+*) echo -ne "Unknown HTTP version: $http_version (1.0, 1.1, 2 or 3)\n"; exit 1 ;;
+esac
+if [ "$http_version" == "3" ] && ! curl --version 2>/dev/null | grep -q '^Features:.* HTTP3'; then
+echo -ne "--http3 needs a curl built with HTTP3 support (see curl --version)\n"
+exit 1
+fi
+if [[ "$dic" =~ ^[A-Za-z]+:// ]]; then
+fetch_wordlist
+fi
+seed=${seed:-$(( `od -An -N4 -tu4 /dev/urandom` % 2147483648 ))}
+config_hash=`{ echo "$version"; printf "%s\n" "${config[@]}"; cat "$dic" secrets.rules disclosure.rules fingerprint.rules 2>/dev/null; } | sha256sum | cut -d' ' -f1`
+scan_id="ghws-${config_hash:0:8}-$seed"
+curl_agent="curl/`curl --version 2>/dev/null | head -1 | cut -d' ' -f2`"
+multi_host=0
+if [ "$hosts" != "" ] || [[ "$server" =~ [0-9]/[0-9]+$ ]]; then
+multi_host=1
+fi
+
+## hmac_sha256 KEY DATA - prints the HMAC-SHA256 of DATA (hex), KEY given as "key:text" or "hexkey:hex" ##
+hmac_sha256() {
+printf "%s" "$2" | openssl dgst -sha256 -mac HMAC -macopt "$1" | sed 's/^.* //'
+}
+
+## sigv4_canonical SERVICE PATH - prints the canonical URI and query of the request PATH for SigV4: every ##
+## path segment URI-encoded twice (once for s3), the query pairs URI-encoded and sorted by key then value ##
+sigv4_canonical() {
+LC_ALL=C awk -v service="$1" -v path="$2" '
+function hex(h) { return (index("0123456789ABCDEF", toupper(substr(h, 1, 1))) - 1) * 16 + index("0123456789ABCDEF", toupper(substr(h, 2, 1))) - 1 }
+function decode(v, out, i, c) { out=""; for (i = 1; i <= length(v); i++) { c=substr(v, i, 1); if (c == "%" && substr(v, i + 1, 2) ~ /^[0-9A-Fa-f][0-9A-Fa-f]$/) { out=out sprintf("%c", hex(substr(v, i + 1, 2))); i+=2 } else out=out c } return out }
+function encode(v, out, i, c) { out=""; for (i = 1; i <= length(v); i++) { c=substr(v, i, 1); out=out (c ~ /[A-Za-z0-9_.~-]/ ? c : sprintf("%%%02X", ord[c])) } return out }
+BEGIN {
+for (i = 1; i < 256; i++) ord[sprintf("%c", i)]=i
+query=""; if (index(path, "?")) { query=substr(path, index(path, "?") + 1); path=substr(path, 1, index(path, "?") - 1) }
+n=split(path, segments, "/"); uri=""
+for (i = 2; i <= n; i++) { segment=encode(decode(segments[i])); if (service != "s3") segment=encode(segment); uri=uri "/" segment }
+print (uri == "" ? "/" : uri)
+n=split(query, pairs, "&")
+for (i = 1; i <= n; i++) if (pairs[i] != "") { j=index(pairs[i], "="); key=j ? substr(pairs[i], 1, j - 1) : pairs[i]; value=j ? substr(pairs[i], j + 1) : ""; print encode(decode(key)) "\t" encode(decode(value)) | "sort -t \"\t\" -k 1,1 -k 2,2" }
+}' | awk -F'\t' 'NR == 1 { print; next } { query=query (query == "" ? "" : "&") $1 "=" $2 } END { print query }'
+}
+
+## sigv4_headers METHOD PATH BODY - prints the AWS Signature Version 4 headers of the request (--aws-sigv4), ##
+## signed with $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY (and $AWS_SESSION_TOKEN)                       ##
+sigv4_headers() {
+local now=`date -u +%Y%m%dT%H%M%SZ` payload query="" canonical scope key step names="host;x-amz-content-sha256;x-amz-date"
+local host=$server uri
+if { [ "$scheme" == "http" ] && [ "$port" != "80" ]; } || { [ "$scheme" == "https" ] && [ "$port" != "443" ]; }; then
+host="$server:$port"
+fi
+{ read -r uri; read -r query; } < <(sigv4_canonical "${aws_sigv4#*/}" "$2")
+payload=`printf "%s" "$3" | sha256sum | cut -d' ' -f1`
+canonical="host:$host\nx-amz-content-sha256:$payload\nx-amz-date:$now\n"
+if [ "$AWS_SESSION_TOKEN" != "" ]; then
+canonical="${canonical}x-amz-security-token:$AWS_SESSION_TOKEN\n"
+names="$names;x-amz-security-token"
+fi
+canonical=`echo -ne "$1\n$uri\n$query\n$canonical\n$names\n$payload" | sha256sum | cut -d' ' -f1`
+scope="${now:0:8}/$aws_sigv4/aws4_request"
+key="key:AWS4$AWS_SECRET_ACCESS_KEY"
+for step in "${now:0:8}" "${aws_sigv4%/*}" "${aws_sigv4#*/}" aws4_request; do
+key="hexkey:`hmac_sha256 "$key" "$step"`"
+done
+echo -ne "X-Amz-Date: $now\r\nX-Amz-Content-Sha256: $payload\r\n"
+if [ "$AWS_SESSION_TOKEN" != "" ]; then
+printf 'X-Amz-Security-Token: %s\r\n' "$AWS_SESSION_TOKEN"
+fi
+echo -ne "Authorization: AWS4-HMAC-SHA256 Credential=$AWS_ACCESS_KEY_ID/$scope, SignedHeaders=$names, Signature=`hmac_sha256 "$key" "$(echo -ne "AWS4-HMAC-SHA256\n$now\n$scope\n$canonical")"`\r\n"
+}
+
+## sign_request METHOD PATH [BODY] - prints the signature headers required by custom API gateways ##
+sign_request() {
+if [ "$aws_sigv4" != "" ]; then
+sigv4_headers "$1" "$2" "$3"
+fi
+if [ "$hmac_key" != "" ]; then
+signature=`printf "%s\n%s\n%s" "$1" "$2" "$server" | openssl dgst -sha256 -hmac "$hmac_key" | sed 's/^.* //'`
+echo -ne "$hmac_header: $signature\r\n"
+fi
+if [ "$signer" != "" ]; then
+$signer "$1" "$2" "$server" | while IFS= read -r header; do
+printf '%s\r\n' "$header"
+done
+fi
+}
+
+## user_agent - prints the User-Agent of the current request (-A, --user-agent-file), rotated over the file per ##
+## request, round-robin or in a random order that is the same for the same seed and host                       ##
+user_agent() {
+local n=${#user_agents[@]}
+if [ $n -eq 1 ]; then
+echo "${user_agents[0]}"
+elif [ "$agent_rotation" == "round-robin" ]; then
+echo "${user_agents[counter % n]}"
+else
+echo "${user_agents[( ( (counter + agent_seed) * 6364136223846793005 + 1442695040888963407 ) >> 33 & 0x7fffffff ) % n]}"
+fi
+}
+
+## spoofed_ip - prints the client address claimed by the current request (--spoof-ip): the fixed address, ##
+## or one drawn per request from the network or, with random, from the public IPv4 space                 ##
+spoofed_ip() {
+local n=$(( ( (counter + agent_seed + 1) * 2862933555777941757 + 3037000493 ) >> 16 & 0xffffffff )) base size client
+case "$spoof_ip" in
+random) client=`int_to_ip $(( ((n >> 24) % 223 + 1 << 24) + (n & 0xffffff) ))`
+while private_address "$client"; do
+n=$(( (n * 2862933555777941757 + 3037000493) >> 16 & 0xffffffff ))
+client=`int_to_ip $(( ((n >> 24) % 223 + 1 << 24) + (n & 0xffffff) ))`
+done
+echo "$client" ;;
+*/*) size=$(( 1 << (32 - ${spoof_ip#*/}) ))
+base=`ip_to_int "${spoof_ip%/*}"`
+int_to_ip $(( (base & ~(size - 1)) + n % size )) ;;
+*) echo "$spoof_ip" ;;
+esac
+}
+
+## content_length TEXT - prints the Content-Length header of TEXT, counted in bytes under LC_ALL=C ##
+content_length() {
+echo -ne "Content-Length: ${#1}\r\n"
+}
+
+## build_request METHOD PATH [HEADERS] [BODY] - prints the raw HTTP request sent to the server ##
+## HEADERS is a "|" separated list of "Name: value" pairs, as found in annotated dictionaries ##
+build_request() {
+local host=$server header name client lines=() given="|"
+if { [ "$scheme" == "http" ] && [ "$port" != "80" ]; } || { [ "$scheme" == "https" ] && [ "$port" != "443" ]; }; then
+host="$server:$port"
+fi
+if [ "$http_version" == "1.1" ]; then
+printf '%s %s HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n' "$1" "$2" "$host"
+else
+printf '%s %s HTTP/1.0\r\nHost: %s\r\n' "$1" "$2" "$host"
+fi
+IFS='|' read -r -a lines <<< "$3"
+for header in "${lines[@]}"; do
+header=${header#"${header%%[! ]*}"}
+given="$given${header%%:*}|"
+printf '%s\r\n' "$header"
+done
+for header in "${custom_headers[@]}"; do
+name=${header%%:*}
+if [[ "${given,,}" != *"|${name,,}|"* ]]; then
+given="$given$name|"
+printf '%s\r\n' "$header"
+fi
+done
+if [ "$spoof_ip" != "" ]; then
+client=`spoofed_ip`
+for name in ${spoof_headers//,/ }; do
+if [[ "${given,,}" == *"|${name,,}|"* ]]; then
+continue
+elif [ "${name,,}" == "forwarded" ] && [[ "$client" == *:* ]]; then
+echo -ne "$name: for=\"[$client]\"\r\n"
+elif [ "${name,,}" == "forwarded" ]; then
+echo -ne "$name: for=$client\r\n"
+else
+echo -ne "$name: $client\r\n"
+fi
+done
+fi
+if [ "${#user_agents[@]}" -gt 0 ] && [[ "${given,,}" != *"|user-agent|"* ]]; then
+printf 'User-Agent: %s\r\n' "`user_agent`"
+fi
+if [[ "${given,,}" != *"|cookie|"* ]]; then
+cookie_header
+fi
+if [ "$authorization" != "" ] && [[ "${given,,}" != *"|authorization|"* ]]; then
+printf 'Authorization: %s\r\n' "$authorization"
+fi
+if [ "$4" != "" ]; then
+LC_ALL=C content_length "$4"
+fi
+sign_request "$1" "$2" "$4"
+echo -ne "\r\n"
+echo -n "$4"
+}
+
+## in_scope - keeps the exported "URL METHOD" lines that belong to the target and turns them into dictionary lines ##
+in_scope() {
+awk -F"$sep" -v OFS="$sep" -v server="$server" -v skipped="${out}output-skipped.txt" '{
+url=$1; sub(/^[a-zA-Z]+:\/\//, "", url); host=url; sub(/\/.*$/, "", host); sub(/:[0-9]+$/, "", host); path=substr(url, length(host) + 1); sub(/^:[0-9]+/, "", path); sub(/^\//, "", path); sub(/#.*$/, "", path)
+if (tolower(host) != tolower(server)) printf "%s\t%s\t%s\t%s\n", server, toupper($2), $1, "out of scope" >> skipped
+else if (seen[$2 " " path]++) printf "%s\t%s\t/%s\t%s\n", server, toupper($2), path, "duplicate entry" >> skipped
+else print path, toupper($2), "", ""
+}'
+}
+
+## add_extensions - also prints every dictionary entry with each of the --extensions appended ##
+add_extensions() {
+awk -F"$sep" -v OFS="$sep" -v extensions="$extensions" '{
+print; if (extensions == "" || $1 == "" || $1 ~ /\/$/) next
+n=split(extensions, ext, ","); path=$1
+for (i = 1; i <= n; i++) if (ext[i] != "") { $1=path ext[i]; print }
+}'
+}
+
+## encode_entries - percent-encodes the path of every dictionary entry (--url-encoding): path-escape only ##
+## the bytes a path cannot carry (space, #, a % not starting an escape, quotes, non-ASCII..), full-escape  ##
+## everything but the unreserved characters and /, raw sends the entries verbatim                          ##
+encode_entries() {
+if [ "$url_encoding" == "raw" ]; then
+cat
+else
+LC_ALL=C awk -F"$sep" -v OFS="$sep" -v mode="$url_encoding" '
+BEGIN { for (i = 1; i < 256; i++) ord[sprintf("%c", i)]=i
+keep=(mode == "full-escape" ? "[-A-Za-z0-9._~/]" : "[-A-Za-z0-9._~!$&'\''()*+,;=:@/?]") }
+{ path=""
+for (i = 1; i <= length($1); i++) { c=substr($1, i, 1)
+if (c ~ keep || (mode == "path-escape" && c == "%" && substr($1, i + 1, 2) ~ /^[0-9A-Fa-f][0-9A-Fa-f]$/)) path=path c
+else path=path sprintf("%%%02X", ord[c]) }
+$1=path; print }'
+fi
+}
+
+## add_variants - with --variants, follows every dictionary entry with its trailing slash (slash) and its ##
+## lower, UPPER and Title case variants (case), once each (an entry already tried as a variant is dropped) ##
+add_variants() {
+if [ "$variants" == "" ]; then
+cat
+else
+awk -F"$sep" -v OFS="$sep" -v variants=",$variants," '
+function title(path,   n, part, i, out) { n=split(path, part, "/"); for (i = 1; i <= n; i++) out=out (i > 1 ? "/" : "") toupper(substr(part[i], 1, 1)) tolower(substr(part[i], 2)); return out }
+function emit(path) { if (!(path in seen)) { seen[path]=1; $1=path query; print } }
+{ path=$1; query=""
+if (match(path, /\?/)) { query=substr(path, RSTART); path=substr(path, 1, RSTART - 1) }
+key=tolower(path); sub(/\/$/, "", key)
+if (key != last) { split("", seen); last=key }
+emit(path)
+if (path == "") next
+if (variants ~ /,slash,/ && path !~ /\/$/ && path !~ /\.[^\/]*$/) emit(path "/")
+if (variants ~ /,case,/) { emit(tolower(path)); emit(toupper(path)); emit(title(path)) }
+}'
+fi
+}
+
+## read_dictionary - prints the dictionary as lines of: path method headers body, separated by $sep ##
+read_dictionary() {
+if [ "$dic_format" == "" ]; then
+case "$dic" in
+*.csv) dic_format=csv ;;
+*.jsonl) dic_format=jsonl ;;
+*.xml) dic_format=burp ;;
+*) dic_format=plain ;;
+esac
+fi
+case "$dic_format" in
+plain) tr -d '\r' < "$dic" | sed "s/^ *//; s/ *$//; s/$/${sep}${default_method}${sep}${sep}/" ;;
+csv) tr -d '\r' < "$dic" | awk -v OFS="$sep" -v method="$default_method" '
+function fields(s,   i, c, n, f, quoted) { n=0; f=""; quoted=0
+for (i = 1; i <= length(s); i++) { c=substr(s, i, 1)
+if (quoted && c == "\"" && substr(s, i + 1, 1) == "\"") { f=f c; i++ }
+else if (c == "\"" && (quoted || f == "")) quoted=!quoted
+else if (c == "," && !quoted) { field[++n]=f; f="" }
+else f=f c }
+field[++n]=f; return n }
+{ n=fields($0); body=field[4]; for (i = 5; i <= n; i++) body=body "," field[i]; sub(/^\//, "", field[1]); print field[1], (field[2] == "" ? method : toupper(field[2])), field[3], body }' ;;
+jsonl) jq -r --arg method "$default_method" '[(.path // "" | ltrimstr("/")), (.method // $method | ascii_upcase), (.headers // {} | if type == "object" then to_entries | map(.key + ": " + .value) else . end | join("|")), (.body // "")] | join("\u001f")' < "$dic" ;;
+burp) grep -o '<url><!\[CDATA\[[^]]*\]\]></url>\|<method><!\[CDATA\[[^]]*\]\]></method>' < "$dic" | sed 's/^<[a-z]*><!\[CDATA\[//; s/\]\]><\/[a-z]*>$//' | awk -v OFS="$sep" '/^[a-zA-Z]+:\/\// { url=$0; next } { print url, $0 }' | in_scope ;;
+paths) echo "$paths" | tr ',' '\n' | sed '/^$/d; s/^\///' | sed "s/$/${sep}${default_method}${sep}${sep}/" ;;
+zap) tr -d '\r' < "$dic" | grep -i '^https\?://' | awk -v OFS="$sep" -v method="$default_method" '{ print $0, method }' | in_scope ;;
+*) echo -ne "Unknown dictionary format: $dic_format\n" >&2 ;;
+esac | add_extensions | encode_entries | dedup | shuffle | add_variants
+}
+
+## page_title FILE - prints the <title> of the answer in FILE, looked for in its first 64 KB with --low-memory ##
+page_title() {
+if [ "$low_memory" == "1" ]; then
+head -c 65536 "$1"
+else
+cat "$1"
+fi | tr -d '\r\n' | grep -o -i '<title[^>]*>[^<]*' | head -1 | sed 's/^<[^>]*>//'
+}
+
+## keep_body FILE - writes the answer read from stdin into FILE, cut at $body_limit bytes with --low-memory; ##
+## the rest is read and dropped so the transfer still completes, the real size of a cut answer kept in     ##
+## FILE.size for body_size                                                                                 ##
+keep_body() {
+local rest
+if [ "$body_limit" != "" ]; then
+head -c "$body_limit" > "$1"
+rest=`wc -c`
+if [ "$rest" -gt 0 ]; then
+echo $(( body_limit + rest )) > "$1.size"
+else
+rm -f "$1.size"
+fi
+else
+cat > "$1"
+fi
+}
+
+## body_size FILE - prints the size in bytes of the body of the answer in FILE, as sent by the server ##
+## when keep_body cut it                                                                             ##
+body_size() {
+local size=`sed '1,/^\r*$/d' "$1" | wc -c`
+if [ -f "$1.size" ]; then
+size=$(( `cat "$1.size"` - `wc -c < "$1"` + size ))
+fi
+echo $size
+}
+
+## dedup - drops the repeated dictionary entries with --dedup, keeping the first one in its place; the ##
+## entries are sorted on disk (--dedup-dir) so that billion-entry dictionaries need little memory    ##
+dedup() {
+if [ "$dedup" == "1" ]; then
+awk -v OFS="$sep" '{ print NR, $0 }' | LC_ALL=C sort -t "$sep" -k 2 -u -s -S $sort_buffer -T "$dedup_dir" | LC_ALL=C sort -t "$sep" -k 1,1n -S $sort_buffer -T "$dedup_dir" | cut -d "$sep" -f 2-
+else
+cat
+fi
+}
+
+## random_source - prints an endless deterministic byte stream derived from the seed ##
+random_source() {
+openssl enc -aes-256-ctr -pass pass:"$seed" -nosalt -pbkdf2 < /dev/zero 2>/dev/null
+}
+
+## shuffle - randomises the dictionary order with --shuffle, the same way for the same seed and host; with ##
+## --low-memory the entries get a random key and are sorted on disk instead of being shuffled in memory     ##
+shuffle() {
+if [ "$shuffle" == "1" ] && [ "$low_memory" == "1" ]; then
+awk -v seed="$(( seed ^ `echo -n "$server" | cksum | cut -d' ' -f1` ))" -v OFS="$sep" 'BEGIN { srand(seed) } { print rand(), $0 }' | LC_ALL=C sort -t "$sep" -k 1,1 -S $sort_buffer -T "$dedup_dir" | cut -d "$sep" -f 2-
+elif [ "$shuffle" == "1" ]; then
+shuf --random-source=<(seed="$seed/$server" random_source)
+else
+cat
+fi
+}
+
+## ip_to_int IP - prints an IPv4 address as an integer ##
+ip_to_int() {
+local IFS=.
+set -- $1
+echo $(( ($1 << 24) + ($2 << 16) + ($3 << 8) + $4 ))
+}
+
+## ipv6_bits IP - prints an IPv6 address (compressed or with an IPv4 tail) as its 128 bits ##
+ipv6_bits() {
+local ip=${1,,} groups=() rest=() group bits="" i n
+ip=${ip#[}
+ip=${ip%]}
+ip=${ip%%\%*}
+if [[ "$ip" =~ ^(.*:)([0-9]+)\.([0-9]+)\.([0-9]+)\.([0-9]+)$ ]]; then
+ip="${BASH_REMATCH[1]}`printf '%x:%x' $(( BASH_REMATCH[2] * 256 + BASH_REMATCH[3] )) $(( BASH_REMATCH[4] * 256 + BASH_REMATCH[5] ))`"
+fi
+if [[ "$ip" == *::* ]]; then
+IFS=: read -ra groups <<< "${ip%%::*}"
+IFS=: read -ra rest <<< "${ip#*::}"
+for (( i = ${#groups[@]} + ${#rest[@]}; i < 8; i++ )); do
+groups+=(0)
+done
+groups+=("${rest[@]}")
+else
+IFS=: read -ra groups <<< "$ip"
+fi
+if [ ${#groups[@]} -ne 8 ]; then
+return 1
+fi
+for group in "${groups[@]}"; do
+if ! [[ "$group" =~ ^[0-9a-f]{1,4}$ ]]; then
+return 1
+fi
+n=$(( 16#$group ))
+for (( i = 15; i >= 0; i-- )); do
+bits="$bits$(( (n >> i) & 1 ))"
+done
+done
+echo "$bits"
+}
+
+## excluded NAME - succeeds when the hostname or address matches an entry of the exclusion list, the ##
+## CIDR entries IPv4 or IPv6                                                                        ##
+excluded() {
+local entry net bits target=""
+if [ "$exclude_hosts" == "" ]; then
+return 1
+fi
+if [[ "$1" == *:* ]]; then
+target=`ipv6_bits "$1"`
+fi
+while read entry; do
+entry=`echo "$entry" | sed 's/#.*$//; s/[ \t]*//g'`
+if [ "$entry" == "" ]; then
+continue
+fi
+if [ "${entry,,}" == "${1,,}" ]; then
+return 0
+fi
+if [[ "$entry" == */* ]] && [[ "$1" =~ ^[0-9]+\.[0-9]+\.[0-9]+\.[0-9]+$ ]] && [[ "$entry" != *:* ]]; then
+net=${entry%/*}
+bits=${entry#*/}
+if [ $(( (`ip_to_int $1` ^ `ip_to_int $net`) >> (32 - bits) )) -eq 0 ]; then
+return 0
+fi
+elif [[ "$entry" == *:* ]] && [ "$target" != "" ]; then
+bits=128
+if [[ "$entry" == */* ]]; then
+bits=${entry#*/}
+fi
+net=`ipv6_bits "${entry%/*}"`
+if [ "${net:0:bits}" == "${target:0:bits}" ]; then
+return 0
+fi
+fi
+done < <(tr -d '\r' < "$exclude_hosts")
+return 1
+}
+
+## skip HOST METHOD PATH REASON - records a skipped request and its reason in output-skipped.txt ##
+skip() {
+echo -e "$1\t$2\t$3\t$4" >> "${out}output-skipped.txt"
+}
+
+## dns_answers FILE - prints the A and AAAA addresses of the DNS wire format answer in FILE ##
+dns_answers() {
+od -An -v -tu1 "$1" | tr -s ' \n' '\n\n' | sed '/^$/d' | awk '
+{ b[NR - 1]=$1 }
+function skip_name(i) { while (b[i] != 0) { if (b[i] >= 192) return i + 2; i+=b[i] + 1 } return i + 1 }
+END {
+if (NR < 12 || b[3] % 16 != 0) exit
+count=b[6] * 256 + b[7]; i=skip_name(12) + 4
+for (n = 0; n < count && i < NR; n++) {
+i=skip_name(i); type=b[i] * 256 + b[i + 1]; size=b[i + 8] * 256 + b[i + 9]; i+=10
+if (type == 1 && size == 4) printf "%d.%d.%d.%d\n", b[i], b[i + 1], b[i + 2], b[i + 3]
+if (type == 28 && size == 16) { ip=""; for (j = 0; j < 16; j+=2) ip=ip (j ? ":" : "") sprintf("%x", b[i + j] * 256 + b[i + j + 1]); print ip }
+i+=size
+} }'
+}
+
+## dns_lookup NAME TYPE - asks --resolver (over TCP) or --doh for the TYPE (1: A, 28: AAAA) records of NAME ##
+dns_lookup() {
+local query=$work/dnsquery.$BASHPID.dat answer=$work/dnsanswer.$BASHPID.dat label size fd
+{ printf '\x47\x48\x01\x00\x00\x01\x00\x00\x00\x00\x00\x00'
+for label in ${1//./ }; do
+printf "%b%s" "$(printf '\\x%02x' ${#label})" "$label"
+done
+printf '\x00\x00%b\x00\x01' "$(printf '\\x%02x' $2)"; } > $query
+if [ "$doh" != "" ]; then
+curl -fsS -H "Content-Type: application/dns-message" -H "Accept: application/dns-message" --data-binary @$query "$doh" -o $answer 2>/dev/null
+elif { exec {fd}<>"/dev/tcp/${resolver%:*}/${resolver##*:}"; } 2>/dev/null; then
+size=`stat -c %s $query`
+{ printf '%b%b' "$(printf '\\x%02x' $(( size / 256 )))" "$(printf '\\x%02x' $(( size % 256 )))"; cat $query; } >&$fd
+size=`timeout 3 dd bs=1 count=2 2>/dev/null <&$fd | od -An -tu1 | awk '{ print $1 * 256 + $2 }'`
+if [ "${size:-0}" -gt 0 ]; then
+timeout 3 dd bs=$size count=1 iflag=fullblock 2>/dev/null <&$fd > $answer
+fi
+exec {fd}>&-
+fi
+if [ -s $answer ]; then
+dns_answers $answer
+fi
+rm -f $query $answer
+}
+
+## resolve_override NAME PORT - prints the address given to NAME with --resolve, the one for PORT first ##
+resolve_override() {
+echo -ne "$resolves" | awk -F'\t' -v name="${1,,}" -v port="$2" '
+$1 == name && $2 == port { print $3; found=1; exit }
+$1 == name && $2 == "" && any == "" { any=$3 }
+END { if (!found && any != "") print any }'
+}
+
+## resolve_host NAME - prints the addresses of NAME, from --resolver or --doh when given, else from the system ##
+resolve_host() {
+if [[ "$1" =~ ^[0-9.]+$|: ]]; then
+echo "$1"
+elif [ "$resolver" != "" ] || [ "$doh" != "" ]; then
+{ dns_lookup "$1" 1; dns_lookup "$1" 28; } | awk '!seen[$0]++'
+else
+getent ahosts "$1" | awk '{ print $1 }' | sort -u
+fi
+}
+
+## cache_address - resolves $server once for the requests of the host, IPv4 first, and again every ##
+## --dns-ttl seconds, telling when the address changes                                           ##
+cache_address() {
+local addresses fresh
+addresses=`resolve_host "$server"`
+fresh=`{ echo "$addresses" | grep -v ':'; echo "$addresses" | grep ':'; } | head -1`
+resolved_at=$EPOCHSECONDS
+if [ "$fresh" != "" ] && [ "$fresh" != "$address" ]; then
+if [ "$address" != "$server" ]; then
+echo -ne "$label\t\t\tDNS: $server now resolves to $fresh (was $address)\n"
+fi
+address=$fresh
+fi
+}
+
+## check_scope - resolves $server into $address, leaving it empty with $skip_reason set when out of scope ##
+check_scope() {
+local addresses ip
+address=""
+if excluded "$server"; then
+skip_reason="excluded host"
+return 1
+fi
+addresses=`resolve_host "$server"`
+if [ "$addresses" == "" ]; then
+skip_reason="unresolvable host"
+return 1
+fi
+for ip in $addresses; do
+if excluded "$ip"; then
+skip_reason="excluded address $ip"
+return 1
+fi
+done
+address=`echo "$addresses" | head -1`
+}
+
+## prefer_family - pins $address to an address of $server of the --prefer-ipv4/--prefer-ipv6 family, ##
+## falling back to the other family when there is none or none answers                                ##
+prefer_family() {
+local addresses preferred other candidate
+addresses=`resolve_host "$server"`
+preferred=`echo "$addresses" | grep -v ':'`
+other=`echo "$addresses" | grep ':'`
+if [ "$prefer_family" == "6" ]; then
+preferred=`echo "$addresses" | grep ':'`
+other=`echo "$addresses" | grep -v ':'`
+fi
+for candidate in $preferred $other; do
+address=$candidate
+if [ "`build_request HEAD / | send_request | head -1`" != "" ]; then
+if [[ " "$other" " == *" $candidate "* ]]; then
+echo -ne "$label\t\t\tNo answer over IPv$prefer_family, falling back to $candidate\n"
+fi
+return 0
+fi
+done
+address=""
+return 1
+}
+
+## fleet_report - collapses the fingerprints of all hosts to the fleet norm and lists the hosts that differ ##
+fleet_report() {
+awk -F"$sep" -v skipped="${out}output-skipped.txt" '{
+fp=$3 " " $4; key=$2 SUBSEP fp; count[key]++; hosts[$2]++; if (!($2 in order)) { order[$2]=++paths; path[paths]=$2 }
+host[NR]=$1; hpath[NR]=$2; hfp[NR]=fp; hstatus[NR]=$3
+if (count[key] > best[$2]) { best[$2]=count[key]; norm[$2]=fp; nstatus[$2]=$3 }
+} END {
+for (i = 1; i <= paths; i++) {
+p=path[i]; if (nstatus[p] !~ /404/) printf "%s\t\t\t%s\t[fleet norm, %d/%d hosts]\n", p, nstatus[p], best[p], hosts[p]
+for (n = 1; n <= NR; n++) if (hpath[n] == p && hfp[n] != norm[p]) printf "%s\t%s\t\t\t%s\t[differs from fleet norm]\n", host[n], p, hstatus[n]
+else if (hpath[n] == p) printf "%s\t*\t/%s\t%s\n", host[n], p, "collapsed into fleet norm" >> skipped
+}
+}' $work/fingerprints.dat
+}
+
+## fleet_matrix - prints a CSV of hosts x interesting paths (any path that did not return 404 on some host) ##
+fleet_matrix() {
+awk -F"$sep" '{
+split($4, status, " "); code=status[2]; if (code == "") code="-"
+if (!($1 in seen)) { seen[$1]=1; host[++hosts]=$1 }
+key=$2 " /" $3; if (!(key in known)) { known[key]=1; path[++paths]=key }
+cell[$1, key]=code; if (code != "404") interesting[key]=1
+} END {
+printf "host"; for (p = 1; p <= paths; p++) if (path[p] in interesting) printf ",%s", path[p]; printf "\n"
+for (h = 1; h <= hosts; h++) {
+printf "%s", host[h]; for (p = 1; p <= paths; p++) if (path[p] in interesting) printf ",%s", cell[host[h], path[p]]; printf "\n"
+}
+}' $work/results.dat
+}
+
+## int_to_ip N - prints an integer as an IPv4 address ##
+int_to_ip() {
+echo "$(( ($1 >> 24) & 255 )).$(( ($1 >> 16) & 255 )).$(( ($1 >> 8) & 255 )).$(( $1 & 255 ))"
+}
+
+## harvest_names IP - prints the reverse DNS names and TLS certificate SANs of an address ##
+harvest_names() {
+{
+getent hosts "$1" | awk '{ for (i = 2; i <= NF; i++) print $i }'
+echo | timeout 5 openssl s_client -connect "$1:443" 2>/dev/null | openssl x509 -noout -ext subjectAltName 2>/dev/null | tr ',' '\n' | grep -o 'DNS:[^ ]*' | cut -d: -f2 | grep -v '^\*'
+} | tr 'A-Z' 'a-z' | sort -u
+}
+
+## expand_target TARGET - prints "host address scheme port" lines to scan; the target may be given ##
+## as [scheme://]host[:port], CIDR ranges become one line per responsive address and harvested    ##
+## name, so IP-range scans hit the real virtual hosts                                              ##
+expand_target() {
+local target=$1 target_scheme="" target_port="" net bits size first ip names name i
+if [[ "$target" =~ ^([a-zA-Z]+)://(.*)$ ]]; then
+target_scheme=${BASH_REMATCH[1],,}
+target=${BASH_REMATCH[2]}
+target=${target%%/*}
+fi
+if [[ "$target" =~ ^([^:/]+):([0-9]+)$ ]]; then
+target=${BASH_REMATCH[1]}
+target_port=${BASH_REMATCH[2]}
+fi
+port=`target_port "${target_scheme:-$scheme}" "$target_port"`
+if [[ "$target" =~ ^[0-9]+\.[0-9]+\.[0-9]+\.[0-9]+/[0-9]+$ ]]; then
+bits=${target#*/}
+size=$(( 1 << (32 - bits) ))
+net=$(( `ip_to_int ${target%/*}` & ~(size - 1) ))
+first=0
+if [ $size -gt 2 ]; then
+first=1
+size=$(( size - 1 ))
+fi
+for (( i = first; i < size; i++ )); do
+ip=`int_to_ip $(( net + i ))`
+if ! timeout 2 bash -c "echo -n > /dev/tcp/$ip/$port" 2>/dev/null; then
+skip "$ip" "*" "*" "no answer on port $port"
+continue
+fi
+names=`harvest_names $ip`
+if [ "$names" == "" ]; then
+names=$ip
+fi
+for name in $names; do
+echo "$name$sep$ip$sep$target_scheme$sep$target_port"
+done
+done
+else
+echo "$target$sep$sep$target_scheme$sep$target_port"
+fi
+}
+
+## target_port SCHEME [PORT] - prints the port to connect to: the target's, then -p, then the scheme's default ##
+target_port() {
+if [ "$2" != "" ]; then
+echo "$2"
+elif [ "$port_option" != "" ]; then
+echo "$port_option"
+elif [ "$1" == "https" ]; then
+echo 443
+else
+echo 80
+fi
+}
+
+## proxy_url URL - prints the curl form of a --proxy or --proxy-file entry: host:port is an http proxy ##
+## and socks5 resolves the names through the proxy                                                ##
+proxy_url() {
+case "$1" in
+socks5://*) echo "socks5h://${1#socks5://}" ;;
+socks5h://*|socks4a://*|http://*|https://*) echo "$1" ;;
+*://*) return 1 ;;
+*) echo "http://$1" ;;
+esac
+}
+
+## rotate_proxy - moves $proxy to the next live proxy of --proxy-file, round-robin; fails when all are dead ##
+rotate_proxy() {
+local i
+for (( i = 0; i < ${#proxies[@]}; i++ )); do
+proxy_turn=$(( (proxy_turn + 1) % ${#proxies[@]} ))
+if ! grep -q -x -F -- "${proxies[proxy_turn]}" $work/deadproxies.dat; then
+proxy=${proxies[proxy_turn]}
+return 0
+fi
+done
+return 1
+}
+
+## send_curl - sends the raw HTTP request read from stdin with curl, through --proxy if any and    ##
+## offering HTTP/$http_version (ALPN for HTTP/2, QUIC for HTTP/3); the answer keeps the raw layout, ##
+## with the negotiated protocol in the status line (HTTP/2.0, HTTP/3.0)                           ##
+send_curl() {
+local method target version header args=() via=() result connect=$address answer=$work/answer.$BASHPID.dat body=$work/body.$BASHPID.dat timing=/dev/null output=(-i -o $work/answer.$BASHPID.dat)
+read -r method target version
+while IFS= read -r header; do
+header=${header%$'\r'}
+if [ "$header" == "" ]; then
+break
+fi
+args+=(-H "$header")
+done
+cat > $body
+if [ -s $body ]; then
+args+=(--data-binary @$body)
+fi
+if [[ "$connect" == *:* ]]; then
+connect="[$connect]"
+fi
+if [ "${#source_ips[@]}" -gt 0 ]; then
+args+=(--interface "${source_ips[source_turn % ${#source_ips[@]}]}")
+fi
+if [ "$unix_socket" != "" ]; then
+args+=(--unix-socket "$unix_socket")
+elif [ "$connect" != "" ] && { [ "$proxy" == "" ] || [ "$pinned_address" == "1" ]; }; then
+args+=(--connect-to "${sni:-$server}:$port:$connect:$port")
+fi
+if [ "$insecure" == "1" ]; then
+args+=(-k)
+fi
+if [ "$method" == "HEAD" ]; then
+args+=(--head)
+else
+args+=(-X "$method")
+fi
+if [ "$connect_timeout" != "" ]; then
+args+=(--connect-timeout "$connect_timeout")
+fi
+if [ "$header_timeout" != "" ]; then
+args+=(--speed-limit 1 --speed-time "$header_timeout")
+fi
+if [ "$request_timeout" != "" ]; then
+args+=(--max-time "$request_timeout")
+fi
+if [ "$ntlm" != "" ]; then
+args+=(--ntlm -u "$ntlm")
+elif [ "$digest" != "" ]; then
+args+=(--digest -u "$digest")
+fi
+if [ "$ntlm$digest" != "" ]; then
+output=(-D $work/headers.$BASHPID.dat -o $work/content.$BASHPID.dat)
+fi
+if [ "$timings" == "1" ] && [ "$job" != "" ]; then
+args+=(-w "%{time_namelookup} %{time_connect} %{time_appconnect} %{time_starttransfer}\n")
+timing=$work/timings.$job.dat
+fi
+: > $answer
+while true; do
+via=()
+if [ "$proxy" != "" ]; then
+via=(--proxy "$proxy" --suppress-connect-headers)
+fi
+curl -s --http${http_version:-1.1} --path-as-is "${args[@]}" "${via[@]}" "${curl_options[@]}" "${output[@]}" "$scheme://${sni:-$server}:$port$target" >> $timing 2>/dev/null
+result=$?
+if [ "$ntlm$digest" != "" ]; then
+{ awk '/^HTTP\// { block="" } { block=block $0 "\n" } END { printf "%s", block }' $work/headers.$BASHPID.dat; cat $work/content.$BASHPID.dat; } > $answer 2>/dev/null
+rm -f $work/headers.$BASHPID.dat $work/content.$BASHPID.dat
+fi
+if [ "${#proxies[@]}" -gt 0 ] && { [ "$result" == "5" ] || [ "$result" == "7" ] || head -1 $answer | grep -q '^HTTP/[0-9.]* 407'; }; then
+if ! grep -q -x -F -- "$proxy" $work/deadproxies.dat; then
+echo "$proxy" >> $work/deadproxies.dat
+echo -ne "Proxy ${proxy#*@} is dead, skipping it\n" >&2
+fi
+if rotate_proxy; then
+continue
+fi
+fi
+break
+done
+if [ "$result" == "28" ]; then
+skip "$server" "$method" "$target" "timeout"
+fi
+sed '1s#^HTTP/\([23]\) #HTTP/\1.0 #' $answer
+rm -f $body $answer
+}
+
+## cookie_header - prints the Cookie header of the request: the --cookie pairs and those the host set ##
+## in the --cookie-jar session, the latter winning                                                  ##
+cookie_header() {
+local pair pairs=() name value=""
+declare -A sent
+IFS=';' read -r -a pairs <<< "$cookies"
+for pair in "${pairs[@]}"; do
+pair=${pair#"${pair%%[! ]*}"}
+if [ "$pair" == "" ]; then
+continue
+fi
+name=${pair%%=*}
+if [ "${session_cookies[$name]+set}" != "set" ] && [ "${sent[$name]}" == "" ]; then
+value="$value${value:+; }$pair"
+sent[$name]=1
+fi
+done
+for name in "${!session_cookies[@]}"; do
+value="$value${value:+; }$name=${session_cookies[$name]}"
+done
+if [ "$value" != "" ]; then
+printf 'Cookie: %s\r\n' "$value"
+fi
+}
+
+## store_cookies HEADERS - keeps in the session the cookies set by the Set-Cookie headers of an ##
+## answer, and forgets those expired by them                                                   ##
+store_cookies() {
+local header pair name attributes expires
+while read -r header; do
+header=${header%$'\r'}
+pair=${header#*:}
+pair=${pair#"${pair%%[! ]*}"}
+attributes=""
+if [[ "$pair" == *\;* ]]; then
+attributes=";${pair#*;}"
+fi
+pair=${pair%%;*}
+name=${pair%%=*}
+if [ "$name" == "" ] || [ "$name" == "$pair" ]; then
+continue
+fi
+expires=`grep -o -i ';[ ]*expires=[^;]*' <<< "$attributes" | sed 's/^[^=]*=//'`
+if [[ "${attributes,,}" =~ \;[\ ]*max-age=(0|-) ]] || { [ "$expires" != "" ] && [ "`date -d "$expires" +%s 2>/dev/null`" -lt $EPOCHSECONDS ] 2>/dev/null; }; then
+unset "session_cookies[$name]"
+continue
+fi
+if [ "${session_cookies[$name]+set}" != "set" ]; then
+printf '%s\t%s /%s\t\tCookie set: %s\n' "$label" "$method" "$line" "$name"
+fi
+session_cookies[$name]=${pair#*=}
+done < <(grep -i '^Set-Cookie:' <<< "$1")
+}
+
+## load_cookies - starts the session of the host with its cookies of the --cookie-jar file ##
+## (Netscape format, as written by curl -c and the browsers extensions)                    ##
+load_cookies() {
+local domain flag path secure expiry name value
+if ! [ -f "$cookie_jar" ]; then
+return
+fi
+while IFS=$'\t' read -r domain flag path secure expiry name value; do
+domain=${domain#\#HttpOnly_}
+if [[ "$domain" == \#* ]] || [ "$name" == "" ]; then
+continue
+fi
+domain=${domain#.}
+if [ "${domain,,}" == "${server,,}" ] || [[ "${server,,}" == *".${domain,,}" ]]; then
+if [ "${expiry:-0}" == "0" ] || [ "$expiry" -gt $EPOCHSECONDS ] 2>/dev/null; then
+session_cookies[$name]=${value%$'\r'}
+fi
+fi
+done < "$cookie_jar"
+}
+
+## save_cookies - writes the session of the host back into the --cookie-jar file, replacing its ##
+## previous cookies, under a lock shared by the jobs                                            ##
+save_cookies() {
+local name
+{
+flock 9
+if [ -f "$cookie_jar" ]; then
+awk -F'\t' -v server="$server" '{ domain=$1; sub(/^#HttpOnly_/, "", domain) } tolower(domain) != tolower(server)' "$cookie_jar" > $work/cookies.$job.dat
+else
+echo "# Netscape HTTP Cookie File" > $work/cookies.$job.dat
+fi
+for name in "${!session_cookies[@]}"; do
+echo -e "$server\tFALSE\t/\tFALSE\t0\t$name\t${session_cookies[$name]}" >> $work/cookies.$job.dat
+done
+mv -f $work/cookies.$job.dat "$cookie_jar"
+} 9> $work/cookies.lock
+}
+
+## login_session - logs in to the host before the dictionary: fetches --login-url for the --login-csrf-regex ##
+## token when given, posts --login-data ({csrf} replaced by the percent-encoded token) and keeps the        ##
+## cookies it sets; fails with $login_reason unless the answer matches --login-success-regex (by default:  ##
+## it set a cookie)                                                                                        ##
+login_session() {
+local method=POST line=${login_url#/} token="" data=$login_data answer before=${#session_cookies[@]}
+login_reason=""
+if [ "$login_csrf_regex" != "" ]; then
+build_request GET "$login_url" | send_request > $work/login.$job.dat
+method=GET store_cookies "`sed '/^\r*$/q' $work/login.$job.dat`"
+if ! [[ "`tr -d '\r' < $work/login.$job.dat`" =~ $login_csrf_regex ]]; then
+login_reason="no CSRF token matching --login-csrf-regex in $login_url"
+rm -f $work/login.$job.dat
+return 1
+fi
+token=${BASH_REMATCH[1]:-${BASH_REMATCH[0]}}
+before=${#session_cookies[@]}
+fi
+token=`jq -r -n --arg token "$token" '$token | @uri'`
+data=${data//\{csrf\}/"$token"}
+build_request POST "$login_url" "Content-Type: application/x-www-form-urlencoded" "$data" | send_request > $work/login.$job.dat
+answer=`sed '/^\r*$/q' $work/login.$job.dat`
+login_status=`head -1 $work/login.$job.dat | tr -d '\r'`
+store_cookies "$answer"
+if [ "$login_success_regex" != "" ] && ! [[ "`tr -d '\r' < $work/login.$job.dat`" =~ $login_success_regex ]]; then
+login_reason="login answered ${login_status:-nothing}, not matching --login-success-regex"
+elif [ "$login_success_regex" == "" ] && [ ${#session_cookies[@]} -le $before ] && ! grep -q -i '^Set-Cookie:' <<< "$answer"; then
+login_reason="login answered ${login_status:-nothing} without setting a cookie"
+fi
+rm -f $work/login.$job.dat
+[ "$login_reason" == "" ]
+}
+
+## logged_out HEADERS - succeeds when the answer says the session expired: it matches --logged-out, by ##
+## default a redirect to --login-url                                                                ##
+logged_out() {
+local pattern=$logged_out_regex
+if [ "$pattern" == "" ]; then
+pattern="^Location:[[:space:]]*([a-z]+://[^/]*)?${login_url//./\\.}([?#]|[[:space:]]*$)"
+fi
+grep -q -i -E "$pattern" <<< "$1"
+}
+
+## retry_after HEADERS - prints the seconds to wait asked by the Retry-After header of HEADERS ##
+## (a delay or an HTTP date), nothing without one                                           ##
+retry_after() {
+local value=`grep -i -m1 '^Retry-After:' <<< "$1" | sed 's/^[^:]*:[ \t]*//; s/\r$//'`
+if [[ "$value" =~ ^[0-9]+$ ]]; then
+echo "$value"
+elif [ "$value" != "" ] && value=`date -d "$value" +%s 2>/dev/null`; then
+echo $(( value > EPOCHSECONDS ? value - EPOCHSECONDS : 0 ))
+fi
+}
+
+## take_slot NAME RATE [COST] - waits for the next of the RATE slots per second of NAME (connects, requests, ##
+## bandwidth), COST of them at once, shared by every job of the scan through $work/NAME.dat                  ##
+take_slot() {
+local now next wait interval=`awk -v rate="$2" -v cost="${3:-1}" 'BEGIN { printf "%d", 1000000 * cost / rate }'`
+{
+flock 9
+now=${EPOCHREALTIME/./}
+read -r next 2>/dev/null < $work/$1.dat
+next=$(( ${next:-0} + interval ))
+if [ $next -lt $now ]; then
+next=$now
+fi
+echo $next > $work/$1.dat
+} 9> $work/$1.lock
+wait=$(( next - now ))
+if [ $wait -gt 0 ]; then
+printf -v wait "%d.%06d" $(( wait / 1000000 )) $(( wait % 1000000 ))
+sleep $wait
+fi
+}
+
+## new_circuit - asks the Tor control port for new circuits (SIGNAL NEWNYM), authenticating with ##
+## $TOR_CONTROL_PASSWORD or the cookie file $TOR_COOKIE                                            ##
+new_circuit() {
+local fd auth='""' reply cookie=${TOR_COOKIE:-/run/tor/control.authcookie}
+if [ "$TOR_CONTROL_PASSWORD" != "" ]; then
+auth="\"${TOR_CONTROL_PASSWORD//\"/\\\"}\""
+elif [ -r "$cookie" ]; then
+auth=`od -An -v -tx1 "$cookie" | tr -d ' \n'`
+fi
+if ! { exec {fd}<>"/dev/tcp/${tor_control%:*}/${tor_control##*:}"; } 2>/dev/null; then
+echo "the Tor control port $tor_control is unreachable"
+return 1
+fi
+printf 'AUTHENTICATE %s\r\nSIGNAL NEWNYM\r\nQUIT\r\n' "$auth" >&$fd
+reply=`timeout 5 cat <&$fd | tr -d '\r' | head -2 | tr '\n' ' '`
+exec {fd}>&-
+if [ "$reply" != "250 OK 250 OK " ]; then
+echo "the control port answered ${reply:-nothing}"
+return 1
+fi
+}
+
+## throttle_download - copies the answer read from stdin in blocks of up to 16 KB, each taking its size ##
+## in bytes of the --max-bandwidth schedule first, so the downloads of all jobs stay under the cap       ##
+throttle_download() {
+local block=$work/block.$BASHPID.dat size
+while dd bs=16384 count=1 iflag=fullblock status=none of=$block && [ -s $block ]; do
+size=`stat -c %s $block`
+take_slot bandwidth "$max_bandwidth" "$size"
+cat $block
+done
+rm -f $block
+}
+
+## uses_curl - succeeds when the requests are sent with curl: through a proxy or unix socket, with timeouts, ##
+## source addresses, timings, NTLM or Digest, or over HTTP/2 and HTTP/3                                  ##
+uses_curl() {
+[ "$proxy" != "" ] || [ "$ntlm$digest" != "" ] || [ "$unix_socket" != "" ] || [ "$connect_timeout$header_timeout$request_timeout" != "" ] || [ "${#source_ips[@]}" -gt 0 ] || [ "$timings" == "1" ] || [ "$http_version" == "2" ] || { [ "$scheme" == "https" ] && [ "$http_version" == "3" ]; }
+}
+
+## egress_address - prints the source address the target logs for the host: the proxy or Tor exit (known only ##
+## to them), else the local address of the route to the target; the --source-ip ones are taken per request    ##
+egress_address() {
+local target=$address
+if [ "$tor" == "1" ]; then
+echo "tor exit"
+elif [ "$proxy" != "" ]; then
+echo "proxy ${proxy##*@}"
+elif [ "$unix_socket" != "" ]; then
+echo "unix socket"
+else
+if ! [[ "$target" =~ ^[0-9.]+$|: ]]; then
+target=`resolve_host "$target" | head -1`
+fi
+ip route get "$target" 2>/dev/null | grep -o 'src [^ ]*' | cut -d' ' -f2
+fi
+}
+
+## correlate REQUEST - passes the answer read from stdin through and appends the raw HTTP request in the file ##
+## REQUEST to output-correlation.csv, in the terms of the access logs of the target: time (ISO 8601 and common ##
+## log format), source address, method, URL, path, user agent, status                                          ##
+correlate() {
+local started=$EPOCHREALTIME agent source=$egress status method target line url path time clf
+if IFS= read -r status; then
+printf '%s\n' "$status"
+cat
+else
+printf '%s' "$status"
+fi
+read -r method target _ < "$1"
+agent=`sed '/^\r*$/q' "$1" | grep -i -m1 '^User-Agent:' | sed 's/^[^:]*:[ \t]*//; s/\r$//'`
+agent=${agent:-$host_agent}
+if [ "${#source_ips[@]}" -gt 0 ]; then
+source=${source_ips[source_turn % ${#source_ips[@]}]}
+fi
+if [[ "$target" == *://* ]]; then
+url=$target
+path=/${target#*://*/}
+else
+line=${target#/}
+url=`finding_url`
+path=$target
+fi
+TZ=UTC printf -v time '%(%Y-%m-%dT%H:%M:%S)T' "${started%.*}"
+TZ=UTC printf -v clf '%(%d/%b/%Y:%H:%M:%S +0000)T' "${started%.*}"
+echo "$time.${started:${#started}-6:3}Z,[$clf],${source:--},$method,\"${url//\"/\"\"}\",\"${path//\"/\"\"}\",\"${agent//\"/\"\"}\",${status:9:3},$scan_id" >> "$correlation"
+}
+
+## send_request - sends the raw HTTP request read from stdin to $address:$port over $scheme, logged to ##
+## output-correlation.csv during a scan                                                                ##
+send_request() {
+local connect=$address verify=() request
+if [ "$max_bandwidth" != "" ] && [ "$throttled" != "1" ]; then
+throttled=1 send_request | throttle_download
+return
+fi
+if [ "$correlation" != "" ]; then
+request=$work/request.$BASHPID.dat
+cat > $request
+correlation="" send_request < $request | correlate $request
+rm -f $request
+return
+fi
+if [ "$max_connects" != "" ]; then
+take_slot connects "$max_connects"
+fi
+if [[ "$connect" == *:* ]]; then
+connect="[$connect]"
+fi
+if [ "$transport" != "" ]; then
+$transport "$scheme" "$address" "$port" "$server"
+elif uses_curl; then
+send_curl
+elif [ "$scheme" == "https" ]; then
+if [ "$insecure" != "1" ]; then
+verify=(-verify_return_error -verify_hostname "${sni:-$server}")
+if [[ "${sni:-$server}" =~ ^[0-9.]+$|: ]]; then
+verify=(-verify_return_error -verify_ip "${sni:-$server}")
+fi
+fi
+openssl s_client -quiet -connect "$connect:$port" -servername "${sni:-$server}" "${verify[@]}" "${tls_options[@]}" 2>/dev/null
+else
+netcat $address $port
+fi
+}
+
+## targets - prints the "host address scheme port" lines of every target given on the command line or in --hosts ##
+targets() {
+if [ "$hosts" != "" ]; then
+tr -d '\r' < "$hosts"
+else
+echo "$server"
+fi | sed 's/^[ \t]*//; s/[ \t]*$//; /^$/d' | while read target; do
+expand_target "$target"
+done
+}
+
+## coverage_summary - prints the ranges of dictionary indices tried against the current host ##
+coverage_summary() {
+sort -n $work/coverage.$job.dat | awk -v host="$label" -v total="$counter" '
+function flush() { if (start != "") ranges=ranges (ranges == "" ? "" : ",") (start == last ? start : start "-" last) }
+{ if (start == "" || $1 != last + 1) { flush(); start=$1 } last=$1; tried++ }
+END { flush(); printf "%s\ttried %d of %d\t%s\n", host, tried, total, (ranges == "" ? "-" : ranges) }'
+}
+
+## timing_phases - turns the cumulative curl timings read from stdin into the milliseconds of each phase: ##
+## dns connect tls server, leaving out the requests that got no answer                                    ##
+timing_phases() {
+awk '$4 > 0 { tls=($3 > 0 ? $3 - $2 : 0); printf "%.1f %.1f %.1f %.1f\n", $1 * 1000, ($2 - $1) * 1000, tls * 1000, ($4 - ($3 > 0 ? $3 : $2)) * 1000 }'
+}
+
+## last_timing - prints the phases of the last request timed with --timings ##
+last_timing() {
+if [ "$timings" == "1" ]; then
+tail -1 $work/timings.$job.dat | timing_phases | awk '{ printf "dns %s, connect %s, tls %s, server %s ms", $1, $2, $3, $4 }'
+fi
+}
+
+## timing_summary - prints the average and slowest time of every phase of the requests sent to the host ##
+## and the phase the time is mostly spent in                                                             ##
+timing_summary() {
+timing_phases < $work/timings.$job.dat | awk -v host="$label" '
+BEGIN { split("dns connect tls server", phase, " ") }
+{ for (i = 1; i <= 4; i++) { sum[i]+=$i; if ($i + 0 > max[i]) max[i]=$i + 0 } n++ }
+END { if (n == 0) exit
+for (i = 1; i <= 4; i++) { line=line (i > 1 ? ", " : "") sprintf("%s %.1f/%.1f ms", phase[i], sum[i] / n, max[i]); if (sum[i] > sum[top]) top=i }
+printf "%s\t%s (avg/max of %d requests), mostly %s\n", host, line, n, (top == 1 ? "DNS" : top == 2 ? "network" : top == 3 ? "TLS" : "server") }'
+}
+
+## body_entropy FILE - prints the Shannon entropy (bits per character) of the body of the answer in FILE ##
+## when it is a small text (up to 4 KB, no markup or binary), the tokens and keys answered as plain text ##
+body_entropy() {
+sed '1,/^\r*$/d' "$1" | head -c 4097 | LC_ALL=C awk '
+{ text=text (NR > 1 ? "\n" : "") $0 }
+END { if (length(text) < 16 || length(text) > 4096 || text ~ /^[ \t\n\r]*</ || text ~ /[\001-\010\016-\037]/) exit
+for (i = 1; i <= length(text); i++) count[substr(text, i, 1)]++
+for (c in count) { p=count[c] / length(text); bits-=p * log(p) / log(2) }
+printf "%.2f\n", bits }'
+}
+
+## secrets_scan FILE [RULES] - prints the names of the patterns of RULES (default: secrets.rules) found in a response ##
+secrets_scan() {
+local name flags regex
+while IFS=$'\t' read -r name flags regex; do
+case "$name" in
+""|"#"*) continue ;;
+esac
+if [ "$flags" == "i" ]; then
+grep -q -i -E -- "$regex" "$1" && echo "$name"
+else
+grep -q -E -- "$regex" "$1" && echo "$name"
+fi
+done < <(tr -d '\r' < "$rules_dir/${2:-secrets.rules}")
+}
+
+## analyzer_secrets FILE - built-in analyzer: a finding per secrets.rules pattern found in the answer ##
+analyzer_secrets() {
+secrets_scan "$1" | sed 's/^/finding /'
+}
+
+## analyzer_listing FILE - built-in analyzer: tags directory listings and asks for the entries they list ##
+analyzer_listing() {
+local base=${line%${line##*/}} href
+if grep -q -i -E '<title>(Index of /|Directory listing for )|\[To Parent Directory\]' "$1"; then
+echo "tag directory listing"
+sed '1,/^\r*$/d' "$1" | grep -o -i 'href="[^"?#]*"' | sed 's/^href="//I; s/"$//' | grep -v -E '^(\.\.?/?|/|[a-zA-Z]+:.*)$' | head -20 | while read -r href; do
+if [ "${href:0:1}" == "/" ]; then
+echo "request $href"
+else
+echo "request /$base$href"
+fi
+done
+fi
+}
+
+## analyzer_backups FILE - built-in analyzer: asks for the backup variants (.bak, .orig, ~, .swp, .map..) ##
+## of the file hit and of the files of the same site its page links to (src, href)                    ##
+analyzer_backups() {
+local base=${line%%[?#]*} file name variant
+base=${base%${base##*/}}
+{ echo "/${line%%[?#]*}"
+sed '1,/^\r*$/d' "$1" | grep -o -i -E '(src|href)="[^"?#]*\.[a-z0-9]{1,5}["?#]' | sed -E 's/^[a-z]*="//I; s/["?#]$//' | grep -v -E '^([a-zA-Z]+:|//)' | head -20 | while read -r file; do
+if [ "${file:0:1}" == "/" ]; then
+echo "$file"
+else
+echo "/$base$file"
+fi
+done; } | sed 's#/\./#/#g' | awk '!seen[$0]++' | while read -r file; do
+name=${file##*/}
+if [[ "$name" != ?*.* ]]; then
+continue
+fi
+for variant in "$file.bak" "$file.old" "$file.orig" "$file.save" "$file~" "$file.tmp" "${file%/*}/.$name.swp" "${file%.*}.bak"; do
+echo "request $variant"
+done
+if [[ "${name,,}" =~ \.(js|css)$ ]]; then
+echo "request $file.map"
+fi
+done
+}
+
+## analyzer_sourcemaps FILE - built-in analyzer: asks for the source map of the JavaScript hits and, on ##
+## a source map, reports its original sources (output-sourcemaps.txt), written to --sourcemap-dir too   ##
+analyzer_sourcemaps() {
+local path=/${line%%[?#]*} map count name file i=0 body=$work/sourcemap.$BASHPID.dat
+sed '1,/^\r*$/d' "$1" > $body
+if [[ "${path,,}" == *.js ]] || grep -q -i -m1 '^Content-Type:.*javascript' "$1"; then
+map=`grep -o -E '[#@] sourceMappingURL=[^ *]+' $body | tail -1 | sed 's/^.*=//' | tr -d '\r'`
+if [[ "$map" == data:* ]]; then
+echo "tag inline source map"
+elif [ "$map" == "" ] || [[ "$map" =~ ^[a-zA-Z]+: ]]; then
+echo "request $path.map"
+elif [ "${map:0:1}" == "/" ]; then
+echo "request $map"
+else
+echo "request ${path%/*}/$map"
+fi
+elif count=`jq -r 'select(.version == 3 and (.sources | type) == "array") | .sources | length' $body 2>/dev/null` && [ "$count" != "" ]; then
+echo "finding exposed source map ($count original sources)"
+echo "tag `jq -r '.sources[:5] | join(", ")' $body`"
+jq -r --arg url "$scheme://$server:$port$path" '.sources[] | $url + "\t" + .' $body >> "${out}output-sourcemaps.txt"
+if [ "$sourcemap_dir" != "" ]; then
+while IFS= read -r name && [ $i -lt 500 ]; do
+file=`echo "$name" | sed -E 's#^[a-zA-Z-]+://+##; s#[^A-Za-z0-9._/@+-]#_#g' | awk -F/ '{ for (i = 1; i <= NF; i++) if ($i != "" && $i != "." && $i != "..") file = file (file == "" ? "" : "/") $i; print file }'`
+if [ "$file" != "" ]; then
+mkdir -p "$sourcemap_dir/$server/`dirname "$file"`"
+jq -j --argjson i $i '.sourcesContent[$i] // empty' $body > "$sourcemap_dir/$server/$file"
+fi
+i=$(( i + 1 ))
+done < <(jq -r '.sources[]' $body)
+echo "tag sources written to $sourcemap_dir/$server"
+fi
+fi
+rm -f $body
+}
+
+## analyzer_webdav FILE - built-in analyzer: on the directories and the answers advertising DAV, asks ##
+## OPTIONS for the DAV classes and PROPFIND (Depth: 1) for the resources, requested as follow-ups      ##
+analyzer_webdav() {
+local path=/${line%%[?#]*} dav listing=$work/webdav.$BASHPID.dat count
+if [ "${path: -1}" != "/" ] && ! grep -q -i -m1 '^DAV:' "$1"; then
+return
+fi
+dav=`sed '/^\r*$/q' "$1" | grep -i -m1 '^DAV:'`
+if [ "$dav" == "" ]; then
+dav=`build_request OPTIONS "$path" | send_request | sed '/^\r*$/q' | grep -i -m1 '^DAV:'`
+fi
+dav=`echo "${dav#*:}" | tr -d '\r' | sed 's/^[[:space:]]*//'`
+if [ "$dav" == "" ]; then
+return
+fi
+echo "tag WebDAV (DAV: $dav)"
+build_request PROPFIND "$path" "Depth: 1|Content-Type: application/xml" '<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>' | send_request > $listing
+if head -1 $listing | grep -q ' 207'; then
+sed '1,/^\r*$/d' $listing | tr -d '\r\n' | grep -o -i -E '<([a-z0-9]+:)?href>[^<]*<' | sed -E 's/^<[^>]*>//; s/<$//; s#^[a-zA-Z]+://[^/]*##' | grep '^/' | grep -v -x -F -e "$path" -e "${path%/}" | awk '!seen[$0]++' | head -100 > $listing.hrefs
+count=`wc -l < $listing.hrefs`
+echo "tag PROPFIND multistatus, $count resources listed"
+sed 's/^/request /' $listing.hrefs
+rm -f $listing.hrefs
+fi
+rm -f $listing
+}
+
+## analyzer_aspnet FILE - built-in analyzer: on the IIS / ASP.NET answers asks for trace.axd, elmah.axd and ##
+## the web.config backups, reports those found with their evidence, and decodes the ViewState of the pages ##
+analyzer_aspnet() {
+local path=/${line%%[?#]*} dir file evidence viewstate strings
+dir=${path%/*}
+case "${path,,}" in
+*/trace.axd) if grep -q -i '<title>[^<]*Application Trace' "$1"; then
+echo "finding ASP.NET tracing exposed ($path lists the requests of the application, with their headers and form values)"
+fi
+return ;;
+*/elmah.axd) evidence=`grep -o -i -m1 '<title>[^<]*Error log for[^<]*' "$1" | sed 's/^<title>//I'`
+if [ "$evidence" != "" ]; then
+echo "finding ELMAH error log exposed ($evidence)"
+fi
+return ;;
+*/web.config?*) if sed '1,/^\r*$/d' "$1" | grep -q -i '<configuration'; then
+evidence=`sed '1,/^\r*$/d' "$1" | grep -o -i -E '<(machineKey|connectionStrings|appSettings|authentication|customErrors)' | sed 's/^<//' | awk '!seen[tolower($0)]++' | paste -s -d ',' | sed 's/,/, /g'`
+echo "finding web.config backup exposed${evidence:+ ($evidence)}"
+fi
+return ;;
+esac
+if ! sed '/^\r*$/q' "$1" | grep -q -i -E '^(Server: Microsoft-IIS|X-Powered-By: ASP\.NET|X-AspNet(Mvc)?-Version:|Set-Cookie: (ASP\.NET_SessionId|\.ASPXAUTH))' && [[ "${path,,}" != *.asp[xh] ]]; then
+return
+fi
+for file in /trace.axd /elmah.axd "$dir/trace.axd" "$dir/elmah.axd" /web.config.bak /web.config.old /web.config.orig /web.config~ /Web.config.bak "$dir/web.config.bak" "$dir/web.config.old"; do
+echo "request $file"
+done | awk '!seen[$0]++'
+viewstate=`grep -o -m1 'id="__VIEWSTATE" value="[^"]*"' "$1" | sed 's/^.*value="//; s/"$//'`
+if [ "$viewstate" != "" ]; then
+if grep -q 'id="__VIEWSTATEENCRYPTED"' "$1"; then
+echo "tag ViewState encrypted (${#viewstate} characters)"
+else
+strings=`echo "$viewstate" | base64 -d 2>/dev/null | LC_ALL=C grep -a -o -E '[[:print:]]{6,}' | head -5 | paste -s -d ',' | sed 's/,/, /g'`
+echo "tag ViewState not encrypted (${#viewstate} characters${strings:+, decodes to: $strings})"
+fi
+fi
+}
+
+## analyzer_php FILE - built-in analyzer: on the PHP answers asks for the phpinfo() pages, composer.json and ##
+## .lock, the .phps source of the script and the Laravel and Symfony debug tools, reported when exposed     ##
+analyzer_php() {
+local path=/${line%%[?#]*} dir file evidence
+dir=${path%/*}
+case "${path,,}" in
+*/phpinfo.php|*/info.php|*/php_info.php|*/test.php|*/i.php|*/_profiler/phpinfo) evidence=`grep -o -m1 -E 'PHP Version [0-9][0-9.]*' "$1" | head -1`
+if [ "$evidence" != "" ] && grep -q -i 'phpinfo()' "$1"; then
+echo "finding phpinfo() exposed ($evidence, with the configuration and environment of the server)"
+fi
+return ;;
+*/composer.json) if sed '1,/^\r*$/d' "$1" | jq -e '.require | type == "object"' > /dev/null 2>&1; then
+echo "finding composer.json exposed (requires `sed '1,/^\r*$/d' "$1" | jq -r '.require | keys | join(", ")'`)"
+fi
+return ;;
+*/composer.lock) evidence=`sed '1,/^\r*$/d' "$1" | jq -r '.packages | select(type == "array") | length' 2>/dev/null`
+if [ "$evidence" != "" ]; then
+echo "finding composer.lock exposed ($evidence packages at their exact versions)"
+fi
+return ;;
+*.phps) if grep -q -E '&lt;\?php|<code><span style="color: #000000">' "$1"; then
+echo "finding PHP source exposed ($path shows the code of ${path%s})"
+fi
+return ;;
+*/telescope|*/horizon|*/_profiler|*/_profiler/latest|*/app_dev.php/_profiler) evidence=`grep -o -i -m1 -E 'Laravel Telescope|Laravel Horizon|Symfony Profiler|sf-toolbar' "$1" | head -1`
+if [ "$evidence" != "" ]; then
+echo "finding debug tool exposed ($evidence at $path)"
+fi
+return ;;
+esac
+if ! sed '/^\r*$/q' "$1" | grep -q -i -E '^(X-Powered-By: PHP|Set-Cookie: (PHPSESSID|laravel_session)|X-Debug-Token:)' && [[ "${path,,}" != *.php ]]; then
+return
+fi
+for file in /phpinfo.php /info.php /php_info.php /test.php /i.php "$dir/phpinfo.php" "$dir/info.php" /composer.json /composer.lock "$dir/composer.json" /telescope /horizon /_profiler /_profiler/latest /_profiler/phpinfo /app_dev.php/_profiler; do
+echo "request $file"
+done | awk '!seen[$0]++'
+if [[ "${path,,}" == *.php ]]; then
+echo "request ${path}s"
+fi
+}
+
+## analyzer_spring FILE - built-in analyzer: on the Java / Spring answers asks for the actuator endpoints, ##
+## reports the exposed ones (env, configprops, heapdump by HEAD..) and turns the routes of mappings into   ##
+## follow-ups                                                                                             ##
+analyzer_spring() {
+local path=/${line%%[?#]*} dir json=$work/spring.$BASHPID.dat count evidence
+dir=${path%/*}
+sed '1,/^\r*$/d' "$1" > $json
+case "${path,,}" in
+*/actuator) if jq -e '._links | type == "object"' $json > /dev/null 2>&1; then
+echo "tag actuator endpoints: `jq -r '._links | keys | join(", ")' $json`"
+jq -r '._links | to_entries[] | select(.key | test("^(heapdump|threaddump|shutdown|logfile|jolokia)$") | not) | .value.href' $json | grep -v '{' | sed -E 's#^[a-zA-Z]+://[^/]*##; s/^/request /'
+spring_heapdump "$path/heapdump"
+fi ;;
+*/env) if jq -e '(.propertySources // .profiles // .activeProfiles) != null' $json > /dev/null 2>&1; then
+evidence=`jq -r '(.activeProfiles // .profiles // []) | join(", ")' $json`
+echo "finding actuator env exposed (`jq -r '.propertySources // [] | length' $json` property sources${evidence:+, active profiles: $evidence})"
+spring_heapdump "$dir/heapdump"
+fi ;;
+*/configprops) if jq -e '.contexts // .beans // . | type == "object"' $json > /dev/null 2>&1 && grep -q -i 'prefix' $json; then
+echo "finding actuator configprops exposed"
+fi ;;
+*/health) evidence=`jq -r '.status // empty' $json 2>/dev/null`
+if [ "$evidence" != "" ]; then
+echo "tag actuator health: $evidence"
+fi ;;
+*/mappings) { jq -r '[.. | objects | select(has("patterns")) | .patterns[]?] | .[]' $json 2>/dev/null
+jq -r 'keys[] | capture("^\\{\\[(?<p>[^\\]]*)\\]") | .p | split(",")[] | ltrimstr(" ")' $json 2>/dev/null; } | grep '^/' | awk '!seen[$0]++' > $json.routes
+count=`wc -l < $json.routes`
+if [ "$count" -gt 0 ]; then
+echo "finding actuator mappings exposed ($count routes)"
+grep -v -E '[{}*]' $json.routes | head -200 | sed 's/^/request /'
+fi
+rm -f $json.routes ;;
+*) if sed '/^\r*$/q' "$1" | grep -q -i -E '^(X-Application-Context:|Set-Cookie: JSESSIONID)' || grep -q -E 'Whitelabel Error Page|"timestamp":[^,]*,"status":[0-9]+,"error":' $json || [[ "${path,,}" =~ \.(jsp|do|action)$ ]]; then
+for file in /actuator /actuator/health /actuator/env /actuator/mappings /actuator/configprops /env /mappings /health /configprops "$dir/actuator"; do
+echo "request $file"
+done | awk '!seen[$0]++'
+fi ;;
+esac
+rm -f $json
+}
+
+## spring_heapdump PATH - reports the actuator heapdump at PATH, asked for with HEAD (it can weigh gigabytes) ##
+spring_heapdump() {
+local answer=`build_request HEAD "$1" | send_request | sed '/^\r*$/q' | tr -d '\r'`
+if [[ "${answer%%$'\n'*}" == *" 200"* ]] && ! echo "$answer" | grep -q -i '^Content-Type: text/html'; then
+echo "finding actuator heapdump exposed ($1`echo "$answer" | grep -i -m1 '^Content-Length:' | sed 's/^[^:]*:[[:space:]]*/, /; s/$/ bytes/'`)"
+fi
+}
+
+## analyzer_title FILE - built-in analyzer: tags the answer with its HTML title ##
+analyzer_title() {
+local title=`page_title "$1"`
+if [ "$title" != "" ]; then
+echo "tag $title"
+fi
+}
+
+## analyzer_tech FILE - built-in analyzer: tags the answer with the framework and version strings of fingerprint.rules ##
+analyzer_tech() {
+fingerprint_scan "$1" | sed 's/^/tag /'
+}
+
+## run_analyzers FILE - runs the --analyzer modules on the raw response in FILE and prints their tags and ##
+## findings as details; findings are kept as secrets and follow-ups are queued for the next follow-up pass ##
+run_analyzers() {
+local name kind text details="" began spent
+for name in "${analyzers[@]}"; do
+if [ "$analyzer_budget" != "" ]; then
+spent=`awk -F'\t' -v module="analyzer $name" '$1 == module { sum+=$2 } END { print int(sum / 1000000) }' $work/profile.$job.dat 2>/dev/null`
+if [ "${spent:-0}" -ge "$analyzer_budget" ]; then
+details="$details\t[$name: skipped, over its budget of ${analyzer_budget}s on this host]"
+continue
+fi
+fi
+began=${EPOCHREALTIME/./}
+while read -r kind text; do
+case "$kind" in
+tag) details="$details\t[$name: $text]" ;;
+finding) details="$details\t[$name finding: $text]"
+echo -e "`finding_url`\t$text" >> $work/secrets.dat ;;
+request) if [ "$pass" != "backfill" ] && [ "$depth" -lt "$follow_up_depth" ]; then
+echo "${text#/}$sep$default_method$sep$sep" >> $work/followups.$job.dat
+fi ;;
+*) continue ;;
+esac
+echo -e "$label\t$method /$line\t\t\t$status\t$name\t$kind\t$text" >> "${out}output-analyzers.txt"
+done < <(if [ "${analyzer_paths[$name]}" == "" ]; then
+analyzer_$name "$1"
+else
+GHWS_URL=`finding_url` GHWS_METHOD=$method GHWS_STATUS=$status "${analyzer_paths[$name]}" "$1" 2>/dev/null
+fi)
+echo -e "analyzer $name\t$(( ${EPOCHREALTIME/./} - began ))" >> $work/profile.$job.dat
+done
+echo "$details"
+}
+
+## pass_entries - prints the entries of the current pass of scan_host: the dictionary, the follow-ups of ##
+## the analyzers or the dropped entries of a backfill round                                            ##
+pass_entries() {
+case "$pass" in
+dictionary) read_dictionary ;;
+follow-ups) cat $work/following.$job.dat ;;
+backfill) cat $work/backfilling.$job.dat ;;
+esac
+}
+
+## backlog [REST] - queues the current entry for --backfill, or with REST every entry of the pass not ##
+## tried yet, when the host is stopped early                                                          ##
+backlog() {
+if [ "$1" == "" ]; then
+echo "$line$sep$method$sep$headers$sep$body" >> $work/backfill.$job.dat
+else
+pass_entries | tail -n +$(( counter - pass_start + 1 )) >> $work/backfill.$job.dat
+fi
+}
+
+## retry_file - appends the entries left in the backlog of the host to output-retry.jsonl, a jsonl dictionary ##
+retry_file() {
+jq -R -c --arg host "$label" 'split("\u001f") | {host: $host, path: ("/" + .[0]), method: .[1], headers: (.[2] // "" | split("|") | map(select(. != ""))), body: (.[3] // "")}' $work/backfill.$job.dat >> "${out}output-retry.jsonl"
+}
+
+## timed MODULE COMMAND.. - runs COMMAND and adds the time it took (microseconds) to MODULE in $work/profile.dat ##
+timed() {
+local began=${EPOCHREALTIME/./} rc
+"${@:2}"
+rc=$?
+echo -e "$1\t$(( ${EPOCHREALTIME/./} - began ))" >> $work/profile.dat
+return $rc
+}
+
+## module_profile - prints the time spent in every module of the pipeline (the requests, each analyzer, ##
+## the deep stage and the sinks), summed over the concurrent jobs, the slowest first                    ##
+module_profile() {
+awk -F'\t' '{ sum[$1]+=$2; calls[$1]++; total+=$2 }
+END { for (m in sum) printf "%s\t%.2f s\t%d%%\t%d calls\n", m, sum[m] / 1000000, total ? sum[m] * 100 / total : 0, calls[m] }' $work/profile.dat 2>/dev/null | sort -t$'\t' -k2,2 -g -r
+}
+
+## follow_ups - moves the follow-up requests the analyzers queued for the current host into the next ##
+## follow-up pass, once each and without the ones of the previous passes (.followed)                  ##
+follow_ups() {
+touch $work/followed.$job.dat
+if [ -f $work/followups.$job.dat ]; then
+awk '!seen[$0]++' $work/followups.$job.dat | grep -v -x -F -f $work/followed.$job.dat
+fi > $work/following.$job.dat
+cat $work/following.$job.dat >> $work/followed.$job.dat
+rm -f $work/followups.$job.dat
+}
+
+## deep_analyze - fetches the body of a triggered hit, scans it for secrets and runs --deep-command ##
+deep_analyze() {
+local job=$BASHPID secrets row
+build_request GET "/$line" "$headers" "$body" | send_request > $work/deep.$job.dat
+secrets=`secrets_scan $work/deep.$job.dat | tr '\n' ' ' | sed 's/ $//'`
+if [ "$secrets" != "" ]; then
+echo -e "`finding_url`\t$secrets" >> $work/secrets.dat
+fi
+echo -e "$label\t$method /$line\t\t\t$status\t[size: `sed '1,/^\r*$/d' $work/deep.$job.dat | wc -c`, secrets: ${secrets:-none}]" >> "${out}output-deep.txt"
+if [ "$deep_command" != "" ]; then
+$deep_command "$server" "$address" "$port" "/$line" "$scheme" < $work/deep.$job.dat 2>&1 | while IFS= read -r row || [ "$row" != "" ]; do
+printf '%s\t/%s\t\t\t%s\n' "$label" "$line" "$row"
+done >> "${out}output-deep.txt"
+fi
+rm -f $work/deep.$job.dat
+}
+
+## deep_stage - second stage fed by the hits matching --deep-trigger, with its own concurrency limit ##
+deep_stage() {
+while IFS=$sep read -r label server address scheme port method line headers body status; do
+timed deep deep_analyze &
+while [ `jobs -rp | wc -l` -ge $deep_threads ]; do
+wait -n
+done
+done
+wait
+}
+
+## results_jsonl - prints the results of the scan as JSON lines ##
+results_jsonl() {
+local states=/dev/null
+if [ -f "$state_file" ]; then
+states=$state_file
+fi
+awk -F"$sep" -v results="$work/results.dat" '
+function str(v) { gsub(/\\/, "\\\\\\\\", v); gsub(/"/, "\\\"", v); gsub(/\t/, "\\t", v); return "\"" v "\"" }
+FILENAME != results { split($0, triage, "\t"); state[triage[1]]=triage[2]; next }
+{ split($4, status, " "); url=$9 "://" $7 (($9 == "http" && $10 == 80) || ($9 == "https" && $10 == 443) ? "" : ":" $10) "/" $3
+printf "{\"host\": %s, \"address\": %s, \"scheme\": %s, \"port\": %s, \"url\": %s, \"method\": %s, \"path\": %s, \"protocol\": %s, \"status\": %s, \"code\": %s, \"size\": %s, \"title\": %s, \"language\": %s, \"disclosure\": %s, \"redirects\": %s, \"family\": %s, \"entropy\": %s, \"time\": %s, \"duration_ms\": %s, \"allow\": %s, \"state\": %s}\n", str($7), ($8 == $7 ? "null" : str($8)), str($9), $10, str(url), str($2), str("/" $3), (status[1] ~ /^HTTP\// ? str(status[1]) : "null"), str($4), (status[2] ~ /^[0-9]+$/ ? status[2] : "null"), ($5 == "" ? "null" : $5), str($6), ($11 == "" ? "null" : str($11)), ($12 == "" ? "null" : str($12)), ($13 == "" ? "null" : str($13)), ($8 ~ /:/ ? "\"ipv6\"" : $8 ~ /^[0-9.]+$/ ? "\"ipv4\"" : "null"), ($14 == "" ? "null" : $14), ($15 == "" ? "null" : str($15)), ($16 == "" ? "null" : $16), ($17 == "" ? "null" : str($17)), (status[2] == "404" ? "null" : str(url in state ? state[url] : "new")) }' "$states" $work/results.dat
+}
+
+## verify_results FILE - re-requests the hits of a previous output-results.jsonl and reports ##
+## which are still present, fixed (404/410 or no answer) or changed                          ##
+verify_results() {
+local code size title now now_code now_size verdict url state
+if [ ! -f "$1" ]; then
+echo -ne "Results file not found: $1\n"
+exit 1
+fi
+rm -f output-verify.txt
+jq -r --arg sep "$sep" 'select(.code != null and .code != 404) | [.host, (.address // .host), (.scheme // "http"), (.port // 80 | tostring), .method, (.path | ltrimstr("/")), (.code | tostring), (.size // "" | tostring), .title] | join($sep)' "$1" | while IFS=$sep read -r server address scheme port method line code size title; do
+sleep 0.10
+if [ "$method" == "HEAD" ] && [ "$size" != "" ]; then
+method=GET
+fi
+build_request "$method" "/$line" | send_request > $work/verify.dat
+now=`head -1 $work/verify.dat | tr -d '\r'`
+now_code=${now:9:3}
+now_size=""
+if [ "$size" != "" ]; then
+now_size=`sed '1,/^\r*$/d' $work/verify.dat | wc -c`
+fi
+if [ "$now_code" == "" ] || [ "$now_code" == "404" ] || [ "$now_code" == "410" ]; then
+verdict="fixed"
+elif [ "$now_code" != "$code" ] || [ "$now_size" != "$size" ]; then
+verdict="changed"
+else
+verdict="present"
+fi
+url=`finding_url`
+state=`state_of "$url"`
+if [ -f "$state_file" ]; then
+if [ "$verdict" == "fixed" ] && [ "$state" != "fixed" ] && [ "$state" != "false-positive" ]; then
+set_state "$url" fixed "verified fixed, was $state"
+state="fixed (was $state)"
+elif [ "$verdict" != "fixed" ] && [ "$state" == "fixed" ]; then
+set_state "$url" new "reappeared after being fixed"
+state="new (reappeared)"
+fi
+fi
+echo -e "$server\t$method /$line\t\t\t$verdict\twas: $code${size:+ ($size bytes)}\tnow: ${now_code:-no answer}${now_size:+ ($now_size bytes)}\t[$state]" | tee -a output-verify.txt
+done
+rm -f $work/verify.dat
+}
+
+## state_of URL - prints the lifecycle state of a finding, "new" when it was never triaged ##
+state_of() {
+local state=""
+if [ -f "$state_file" ]; then
+state=`awk -F'\t' -v url="$1" '$1 == url { state=$2 } END { print state }' "$state_file"`
+fi
+echo "${state:-new}"
+}
+
+## set_state URL STATE [NOTE] - records the lifecycle state of a finding in $state_file ##
+set_state() {
+case "$2" in
+new|confirmed|false-positive|fixed|accepted-risk) ;;
+*) echo -ne "Unknown state: $2 (new, confirmed, false-positive, fixed, accepted-risk)\n"; return 1 ;;
+esac
+touch "$state_file"
+awk -F'\t' -v url="$1" '$1 != url' "$state_file" > "$state_file.tmp"
+echo -e "$1\t$2\t`date -u +%Y-%m-%dT%H:%M:%SZ`\t$3" >> "$state_file.tmp"
+mv "$state_file.tmp" "$state_file"
+}
+
+## query_filter EXPRESSION - compiles a query ("status==200 && length>10000 && path~\"backup\"") into a jq ##
+## filter; the fields are those of output-results.jsonl, status and length meaning code and size         ##
+query_filter() {
+echo "$1" | awk '
+function error(message) { print "Invalid query: " message > "/dev/stderr"; exit 1 }
+function next_token() {
+sub(/^[ \t]+/, "", text)
+if (text == "") { token=""; return }
+if (match(text, /^"([^"\\]|\\.)*"/) || match(text, /^(&&|\|\||==|!=|>=|<=|!~|[<>~!()])/) || match(text, /^-?[0-9]+(\.[0-9]+)?/) || match(text, /^[a-z_]+/)) {
+token=substr(text, 1, RLENGTH); text=substr(text, RLENGTH + 1); return }
+error("unexpected " substr(text, 1, 10))
+}
+function expr(   left) { left=term(); while (token == "&&" || token == "||") { op=(token == "&&" ? " and " : " or "); next_token(); left="(" left op term() ")" } return left }
+function term(   inner, field, op, value) {
+if (token == "!") { next_token(); return "(" term() " | not)" }
+if (token == "(") { next_token(); inner=expr(); if (token != ")") error("missing )"); next_token(); return inner }
+if (token !~ /^[a-z_]+$/) error("field expected, got " (token == "" ? "the end" : token))
+field=(token == "status" ? "code" : token == "length" ? "size" : token)
+if (!(field in fields)) error("unknown field " token)
+next_token(); op=token
+if (op !~ /^(==|!=|>=|<=|>|<|~|!~)$/) error("operator expected after " field)
+next_token(); value=token
+if (value !~ /^("|-?[0-9])/) error("value expected after " op)
+next_token()
+if (op == "~") return "((." field " // \"\" | tostring) | test(" value "; \"i\"))"
+if (op == "!~") return "((." field " // \"\" | tostring) | test(" value "; \"i\") | not)"
+return "(." field " " op " " value ")"
+}
+{ split("host address scheme port url method path protocol code size title language disclosure redirects family entropy state", names, " ")
+for (i in names) fields[names[i]]
+text=$0; next_token(); filter=expr()
+if (token != "") error("unexpected " token)
+print "select(" filter ")" }'
+}
+
+## success_baseline - records the length of the answer to a random path, the baseline of --success-expr ##
+success_baseline() {
+build_request GET "/ghws-$RANDOM$RANDOM" | send_request > $work/calibrate.$job.dat
+baseline_length=`sed '1,/^\r*$/d' $work/calibrate.$job.dat | wc -c`
+baseline_code=`head -1 $work/calibrate.$job.dat | cut -d' ' -f2`
+baselines=()
+if [[ "$baseline_code" =~ ^[0-9]{3}$ ]]; then
+baselines[$baseline_code]=$baseline_length
+fi
+rm -f $work/calibrate.$job.dat
+}
+
+## variant_check - with --variants, compares the answer of a variant with the one of its entry (status, ##
+## Content-Length and Location), the case variants of entry/ with entry/: the same answer is merged into ##
+## the entry, a different one is tagged                                                                  ##
+variant_check() {
+local path=${line%%\?*} key signature base reference
+key=${path,,}
+key=${key%/}
+signature=`echo "$answer" | tr -d '\r' | sed -n -E 's/^(content-length|location):[[:space:]]*//Ip' | paste -s -d '|'`
+signature="${status:9:3}|${signature,,}"
+if [ "$key" != "$variant_key" ]; then
+variant_key=$key
+variant_base=$line
+variant_signature=$signature
+slash_base=""
+if [ "${path: -1}" == "/" ]; then
+slash_base=$line
+slash_signature=$signature
+fi
+return
+fi
+base=$variant_base
+reference=$variant_signature
+if [ "${path: -1}" == "/" ] && [ "$slash_base" != "" ]; then
+base=$slash_base
+reference=$slash_signature
+elif [ "${path: -1}" == "/" ]; then
+slash_base=$line
+slash_signature=$signature
+fi
+if [ "$signature" == "$reference" ]; then
+merged=1
+if [ "${status:9:3}" != "404" ]; then
+skip "$label" "$method" "/$line" "same answer as /$base"
+fi
+else
+details="$details\t[variant of /$base: ${reference%%|*} there]"
+fi
+}
+
+## is_hit - succeeds when the answer is a hit: by --success-expr when given, else when it is not a 404 ##
+is_hit() {
+local found
+if [ "$success_expr" == "" ]; then
+[ "${status:9:3}" != "404" ]
+return
+fi
+shopt -s nocasematch
+eval "[[ ${success_parts[0]} ]]"
+found=$?
+shopt -u nocasematch
+return $found
+}
+
+## query_results EXPRESSION [FILE] [--json] - prints the results of FILE (default: output-results.jsonl) ##
+## matching the query, as status, size, URL and title or, with --json, as the JSON lines themselves   ##
+query_results() {
+local expression="" file="output-results.jsonl" json=0 filter argument
+for argument in "$@"; do
+case "$argument" in
+--json) json=1 ;;
+*) if [ "$expression" == "" ]; then expression=$argument; else file=$argument; fi ;;
+esac
+done
+if [ "$expression" == "" ]; then
+echo -ne "Usage: ./${0##*/} query EXPRESSION [output-results.jsonl] [--json]\n"
+echo -ne "  fields: status (the code), length (the size), path, url, host, method, title, state, language..\n"
+echo -ne "  operators: == != > >= < <= ~ (regular expression) !~ && || ! ( ), strings in double quotes\n"
+exit 1
+fi
+if [ ! -f "$file" ]; then
+echo -ne "Results file not found: $file\n"
+exit 1
+fi
+if ! filter=`query_filter "$expression"`; then
+exit 1
+fi
+if [ "$json" == "1" ]; then
+jq -c "$filter" "$file"
+else
+jq -r "$filter | [(.code // \"-\" | tostring), (.size // \"-\" | tostring), .url, .title] | join(\"\\t\")" "$file"
+fi
+}
+
+## digest_report [FILE] [--format markdown|html] [--mail address] - reports the hits of FILE (default:  ##
+## output-results.jsonl) that appeared, were fixed or changed since the previous digest, with the trend ##
+digest_report() {
+local file="output-results.jsonl" format=markdown mail="" report now=`date -u "+%Y-%m-%d %H:%M"`
+while [ $# -gt 0 ]; do
+case "$1" in
+--format) format=$2; shift ;;
+--mail) mail=$2; shift ;;
+*) file=$1 ;;
+esac
+shift
+done
+if [ ! -f "$file" ]; then
+echo -ne "Results file not found: $file\n"
+exit 1
+fi
+if [ "$format" != "markdown" ] && [ "$format" != "html" ]; then
+echo -ne "Unknown digest format: $format (markdown or html)\n"
+exit 1
+fi
+touch .digest.jsonl digest-history.txt
+jq -c 'select(.code != null and .code != 404 and .state != "false-positive") | {key: (.method + " " + .url), status, title, host}' "$file" > .digest.new.dat
+jq -n -r --slurpfile before .digest.jsonl --slurpfile after .digest.new.dat --rawfile history digest-history.txt --arg now "$now" --arg format "$format" '
+($before | map({key, value: .}) | from_entries) as $old | ($after | map({key, value: .}) | from_entries) as $new |
+([$after[] | select($old[.key] == null)]) as $appeared | ([$before[] | select($new[.key] == null)]) as $fixed |
+([$after[] | select($old[.key] != null and $old[.key].status != .status)]) as $changed |
+[$now, ($after | length), ($appeared | length), ($fixed | length)] as $today |
+($history | split("\n") | map(select(. != "") | split("\t")) + [$today] | .[-10:]) as $trend |
+def rows(list): if (list | length) == 0 then ["none"] else list | map("\(.status) \(.key)" + (if .title != "" then " (\(.title))" else "" end)) end;
+def section(name; lines): if $format == "html" then "<h2>\(name)</h2>\n<ul>\n" + (lines | map("<li>" + @html "\(.)" + "</li>") | join("\n")) + "\n</ul>" else "\n## \(name)\n\n" + (lines | map("- " + .) | join("\n")) end;
+($today | map(tostring) | join("\t")),
+(if $format == "html" then "<h1>gHybridWebSearch digest, \($now) UTC</h1>\n<p>\($after | length) hits on \($after | map(.host) | unique | length) hosts</p>" else "# gHybridWebSearch digest, \($now) UTC\n\n\($after | length) hits on \($after | map(.host) | unique | length) hosts" end),
+section("New exposure"; rows($appeared)),
+section("Fixed"; rows($fixed)),
+section("Changed"; $changed | map(. as $hit | "\($old[$hit.key].status) -> \($hit.status) \($hit.key)") | if length == 0 then ["none"] else . end),
+section("Trend (date, hits, new, fixed)"; $trend | map(map(tostring) | join(" | ")))' > .digest.report.dat
+report="output-digest.md"
+if [ "$format" == "html" ]; then
+report="output-digest.html"
+fi
+head -1 .digest.report.dat >> digest-history.txt
+sed '1d' .digest.report.dat > "$report"
+mv -f .digest.new.dat .digest.jsonl
+rm -f .digest.report.dat
+cat "$report"
+if [ "$mail" != "" ] && ! command -v sendmail > /dev/null; then
+echo -ne "\nNot mailed to $mail: sendmail was not found\n"
+elif [ "$mail" != "" ]; then
+{ echo "To: $mail"
+echo "Subject: gHybridWebSearch digest, $now UTC"
+if [ "$format" == "html" ]; then
+echo "Content-Type: text/html; charset=utf-8"
+fi
+echo
+cat "$report"; } | sendmail -t
+fi
+}
+
+## state_command set|list .. - triage of the findings from the command line ##
+state_command() {
+case "$1" in
+set) set_state "$2" "$3" "$4" && echo -ne "$2\t$3\n" ;;
+list) if [ -f "$state_file" ]; then awk -F'\t' -v state="$2" 'state == "" || $2 == state' "$state_file"; fi ;;
+*) echo -ne "Usage: ./${0##*/} state set URL STATE [note] | state list [state]\n" ;;
+esac
+}
+
+## finding_url - prints the URL identifying the current request as a finding ##
+finding_url() {
+if { [ "$scheme" == "http" ] && [ "$port" == "80" ]; } || { [ "$scheme" == "https" ] && [ "$port" == "443" ]; }; then
+echo "$scheme://$server/$line"
+else
+echo "$scheme://$server:$port/$line"
+fi
+}
+
+## defectdojo_export - prints the hits of output-results.jsonl as DefectDojo "Generic Findings Import" JSON ##
+defectdojo_export() {
+touch $work/secrets.dat
+jq -n --rawfile secrets $work/secrets.dat --arg date "`date -u +%Y-%m-%d`" '
+($secrets | split("\n") | map(select(. != "") | split("\t")) | group_by(.[0]) | map({key: .[0][0], value: (map(.[1]) | unique | join(", "))}) | from_entries) as $found |
+{findings: [inputs | select(.code != null and .code != 404 and .state != "false-positive") |
+($found[.url] // "") as $secret |
+(if $secret != "" then "High"
+elif .entropy != null and .code == 200 then "Medium"
+elif .code == 200 and (.path | test("(\\.(bak|old|orig|save|swp|tmp|copy|zip|tar|gz|tgz|rar|7z|sql|db|sqlite|env|log|conf|config|ini|pem|key)|~)$|/\\.(git|svn|env|htaccess|htpasswd)"; "i")) then "Medium"
+elif .code == 200 then "Low"
+else "Info" end) as $severity |
+{title: ("Exposed " + (if $secret != "" then "secrets in " else "" end) + .path + " (" + (.code | tostring) + ")"),
+date: (.time // $date | .[0:10]),
+severity: $severity,
+description: ("URL: " + .url + "\nRequest: " + .method + " " + .path + "\nResponse: " + .status + (if .size != null then "\nSize: " + (.size | tostring) + " bytes" else "" end) + (if .title != "" then "\nTitle: " + .title else "" end) + (if $secret != "" then "\nSecrets: " + $secret else "" end) + (if .entropy != null then "\nHigh entropy content: " + (.entropy | tostring) + " bits/char" else "" end) + (if .allow != null then "\nAllowed methods: " + .allow else "" end) + (if .time != null then "\nRequested: " + .time + " (" + (.duration_ms | tostring) + " ms)" else "" end)),
+mitigation: "Remove the file from the web root or restrict access to it, and rotate any credential it exposed.",
+unique_id_from_tool: (.method + " " + .url),
+vuln_id_from_tool: "gHybridWebSearch",
+endpoints: [{protocol: .scheme, host: .host, port: .port, path: .path}],
+active: (.state != "fixed"),
+verified: (.state == "confirmed"),
+false_p: false,
+risk_accepted: (.state == "accepted-risk"),
+is_mitigated: (.state == "fixed")}]}' "${out}output-results.jsonl"
+}
+
+## top_findings - ranks the hits of output-results.jsonl (secrets, leaks, sensitive names, answers rare across ##
+## the scan, sizes far above the median of the host) and prints the --top best as: score status URL [reasons]   ##
+top_findings() {
+touch $work/secrets.dat
+jq -n -r --rawfile secrets $work/secrets.dat --argjson top "$top" '
+($secrets | split("\n") | map(select(. != "") | split("\t")) | group_by(.[0]) | map({key: .[0][0], value: (map(.[1]) | unique | join(", "))}) | from_entries) as $found |
+[inputs | select(.code != null and .code != 404 and .state != "false-positive")] as $hits |
+($hits | map("\(.code) \(.size // .title)") | group_by(.) | map({key: .[0], value: length}) | from_entries) as $signatures |
+($hits | group_by(.host) | map({key: .[0].host, value: (map(.size // empty) | sort | if length > 0 then .[length / 2 | floor] else null end)}) | from_entries) as $medians |
+[$hits[] | . as $hit | ($found[.url] // "") as $secret | [
+(if $secret != "" then [50, "secrets: " + $secret] else empty end),
+(if .disclosure then [20, "leaks: " + .disclosure] else empty end),
+(if .entropy then [15, "high entropy"] else empty end),
+(if .allow // "" | test("\\b(PUT|DELETE|PATCH|TRACE|TRACK|CONNECT|DEBUG|PROPPATCH|MKCOL|MOVE|COPY|LOCK|UNLOCK|SEARCH)\\b") then [15, "dangerous methods"] else empty end),
+(if .path | test("(\\.(bak|old|orig|save|swp|tmp|copy|zip|tar|gz|tgz|rar|7z|sql|db|sqlite|env|log|conf|config|ini|pem|key)|~)$|/\\.(git|svn|env|htaccess|htpasswd)"; "i") then [20, "sensitive file"] else empty end),
+(if .path | test("admin|backup|config|debug|secret|private|internal|dump|passw|token|console"; "i") then [10, "sensitive name"] else empty end),
+(if .code == 200 then [10, "200"] elif .code == 401 or .code == 403 then [5, "protected"] elif .code >= 500 then [5, "server error"] else empty end),
+($signatures["\(.code) \(.size // .title)"] as $count | if $count == 1 and ($hits | length) > 3 then [15, "unique answer"] elif $count <= 3 and ($hits | length) > 10 then [8, "rare answer"] else empty end),
+(if .size != null and $medians[.host] != null and $medians[.host] > 0 and .size > 10 * $medians[.host] then [10, "size outlier"] else empty end)
+] | {hit: $hit, score: (map(.[0]) | add // 0), reasons: map(.[1])}] |
+sort_by(-.score) | .[:$top][] | "\(.score)\t\(.hit.status)\t\(.hit.url)\t[\(.reasons | join(", "))]"' "${out}output-results.jsonl"
+}
+
+## defectdojo_upload - imports output-defectdojo.json into the --defectdojo-engagement of --defectdojo-url ##
+defectdojo_upload() {
+echo -ne "Uploading the findings to $defectdojo_url (engagement $defectdojo_engagement)..\n"
+if curl -fsS -X POST "${defectdojo_url%/}/api/v2/import-scan/" -H "Authorization: Token $defectdojo_token" -F "scan_type=Generic Findings Import" -F "engagement=$defectdojo_engagement" -F "file=@${out}output-defectdojo.json" > /dev/null; then
+echo -ne "Upload done.\n"
+else
+echo -ne "Upload failed, output-defectdojo.json can be imported by hand.\n"
+fi
+}
+
+## open_issue_titles - prints the titles of the open issues opened by the script in the tracker, every ##
+## page of them                                                                                       ##
+open_issue_titles() {
+local page=1 start=0 answer count total project
+if [ "$create_issues" == "github" ]; then
+while answer=`curl -fsS -H "Authorization: Bearer $GITHUB_TOKEN" -H "Accept: application/vnd.github+json" "$github_api/repos/$github_repo/issues?state=open&labels=gHybridWebSearch&per_page=100&page=$page"`; do
+echo "$answer" | jq -r '.[].title'
+if [ "`echo "$answer" | jq 'length'`" -lt 100 ]; then
+return 0
+fi
+page=$(( page + 1 ))
+done
+return 1
+fi
+project=`jq -n -r --arg key "$jira_project" '$key | @json'`
+while answer=`curl -fsS -u "$JIRA_USER:$JIRA_TOKEN" -G "${jira_url%/}/rest/api/2/search" --data-urlencode "jql=project = $project AND labels = gHybridWebSearch AND statusCategory != Done" --data-urlencode "fields=summary" --data-urlencode "startAt=$start" --data-urlencode "maxResults=100"`; do
+echo "$answer" | jq -r '.issues[].fields.summary'
+count=`echo "$answer" | jq '.issues | length'`
+total=`echo "$answer" | jq '.total // 0'`
+start=$(( start + count ))
+if [ "$count" -eq 0 ] || [ "$start" -ge "$total" ]; then
+return 0
+fi
+done
+return 1
+}
+
+## create_issue TITLE DESCRIPTION - opens an issue in the --create-issues tracker, DESCRIPTION is a JSON string ##
+create_issue() {
+if [ "$create_issues" == "github" ]; then
+jq -n --arg title "$1" --argjson body "$2" '{title: $title, body: $body, labels: ["gHybridWebSearch", "security"]}' | curl -fsS -X POST -H "Authorization: Bearer $GITHUB_TOKEN" -H "Accept: application/vnd.github+json" "$github_api/repos/$github_repo/issues" -d @- > /dev/null
+else
+jq -n --arg project "$jira_project" --arg title "$1" --argjson body "$2" '{fields: {project: {key: $project}, summary: $title, description: $body, issuetype: {name: "Bug"}, labels: ["gHybridWebSearch"]}}' | curl -fsS -X POST -u "$JIRA_USER:$JIRA_TOKEN" -H "Content-Type: application/json" "${jira_url%/}/rest/api/2/issue" -d @- > /dev/null
+fi
+}
+
+## create_issues - opens one issue per high severity finding, unless an open issue already has its title ##
+create_issues() {
+local existing title description
+if [ "$create_issues" == "github" ] && [ "$github_repo" == "" ]; then
+echo -ne "--create-issues github needs --github-repo owner/repo (and GITHUB_TOKEN).\n"
+return
+elif [ "$create_issues" == "jira" ] && { [ "$jira_url" == "" ] || [ "$jira_project" == "" ]; }; then
+echo -ne "--create-issues jira needs --jira-url and --jira-project (and JIRA_USER, JIRA_TOKEN).\n"
+return
+elif [ "$create_issues" == "jira" ] && ! [[ "$jira_project" =~ ^[A-Z][A-Z0-9_]*$ ]]; then
+echo -ne "Invalid --jira-project: $jira_project (a project key, e.g. SEC)\n"
+return
+fi
+if ! existing=`open_issue_titles`; then
+echo -ne "Could not list the open $create_issues issues, no issue was created.\n"
+return
+fi
+defectdojo_export | jq -r --arg sep "$sep" '.findings[] | select(.severity == "High") | ["[gHybridWebSearch] " + .title + " on " + .endpoints[0].host, (.description | @json)] | join($sep)' | while IFS=$sep read -r title description; do
+if echo "$existing" | grep -q -x -F -- "$title"; then
+echo -ne "Issue already open: $title\n"
+elif create_issue "$title" "$description"; then
+echo -ne "Issue created: $title\n"
+else
+echo -ne "Could not create the issue: $title\n"
+fi
+done
+}
+
+## page_language FILE - prints the language of the raw response in FILE: the <html lang> attribute, ##
+## then the Content-Language header, then a guess from the most common words of the text          ##
+page_language() {
+local lang
+lang=`tr -d '\r\n' < "$1" | grep -o -i '<html[^>]*[[:space:]]lang=["'\'']\{0,1\}[a-zA-Z-]*' | head -1 | sed 's/.*=["'\'']\{0,1\}//'`
+if [ "$lang" == "" ]; then
+lang=`sed '/^\r*$/q' "$1" | grep -i '^Content-Language:' | head -1 | sed 's/^[^:]*:[ \t]*//; s/[ ,;\r].*//'`
+fi
+if [ "$lang" == "" ]; then
+lang=`sed '1,/^\r*$/d' "$1" | sed 's/<[^>]*>/ /g' | tr 'A-Z' 'a-z' | tr -cs 'a-z' '\n' | awk '
+BEGIN { split("en the and of to is that for with you this|de der die und das ist nicht mit sie ein auch|fr le les et des est une pour dans que sur|es el los las y por una con para del como|it il di che per non una sono della gli anche|pt os do da em um para com uma mais pelo|nl het een en van dat op te niet voor zijn", langs, "|")
+for (i in langs) { n=split(langs[i], w, " "); for (j = 2; j <= n; j++) words[w[j]]=words[w[j]] " " w[1] } }
+$0 in words { n=split(words[$0], found, " "); for (i = 1; i <= n; i++) score[found[i]]++ }
+END { for (l in score) if (score[l] > best) { best=score[l]; lang=l } if (best >= 5) print lang }'`
+fi
+echo "${lang,,}"
+}
+
+## wanted_language LANG - succeeds when LANG (or its primary subtag) is in --language, or is unknown ##
+wanted_language() {
+[ "$languages" == "" ] || [ "$1" == "" ] || [[ ",$languages," == *",$1,"* ]] || [[ ",$languages," == *",${1%%-*},"* ]]
+}
+
+## header_profile FILE - prints the notable headers of the raw response in FILE as "name: value" ##
+## lines, and the name of every cookie it sets as "set-cookie: name"                            ##
+header_profile() {
+sed '1d; /^\r*$/q' "$1" | tr -d '\r' | awk '
+{ i=index($0, ":"); if (i == 0) next; name=tolower(substr($0, 1, i - 1)); value=substr($0, i + 1); sub(/^[ \t]+/, "", value) }
+name == "set-cookie" { sub(/=.*/, "", value); print name ": " value; next }
+name ~ /^(server|x-powered-by|x-aspnet(mvc)?-version|via|x-backend.*|x-served-by|x-server|x-upstream.*|x-runtime|x-generator|x-cache|x-debug.*|x-.*-debug.*)$/ { print name ": " value }' | sort -u
+}
+
+## header_anomalies BASELINE HIT - prints the differences of the HIT header profile from the BASELINE one: ##
+## new cookies, debug headers and notable headers that are new or changed                                 ##
+header_anomalies() {
+awk -v baseline="$1" '
+FILENAME == baseline { i=index($0, ": "); key=substr($0, 1, i - 1); seen[key]=seen[key] "|" substr($0, i + 2) "|"; known[key]=1; next }
+{ i=index($0, ": "); key=substr($0, 1, i - 1); value=substr($0, i + 2) }
+index(seen[key], "|" value "|") { next }
+key == "set-cookie" { out=out (out == "" ? "" : ", ") "new cookie " value; next }
+key ~ /debug/ { out=out (out == "" ? "" : ", ") "debug header " key ": " value; next }
+key in known { was=seen[key]; gsub(/^\||\|$/, "", was); gsub(/\|\|/, ", ", was); out=out (out == "" ? "" : ", ") key ": " was " -> " value; next }
+{ out=out (out == "" ? "" : ", ") "new " key ": " value }
+END { print out }' "$1" "$2"
+}
+
+## fingerprint_scan FILE - prints the framework and version strings of fingerprint.rules found in a response ##
+fingerprint_scan() {
+local name flags regex
+while IFS=$'\t' read -r name flags regex; do
+case "$name" in
+""|"#"*) continue ;;
+esac
+if [ "$flags" == "i" ]; then
+grep -o -a -i -E -m 1 -- "$regex" "$1" | head -1
+else
+grep -o -a -E -m 1 -- "$regex" "$1" | head -1
+fi
+done < <(tr -d '\r' < "$rules_dir/fingerprint.rules") | tr -d '\r' | sed 's/^\(X-Powered-By\|Server\): //I'
+}
+
+## error_pages - requests a few malformed paths (overlong names, illegal characters, reserved device ##
+## names) to provoke verbose error pages, and prints the frameworks and versions they reveal         ##
+error_pages() {
+local path status found all=""
+for path in "/`printf 'A%.0s' {1..300}`" "/%" "/%00" "/%ff%fe" "/%3C%3E%22" "/..;/" "/[" "/CON" "/AUX.aspx" "/NUL.txt" "/LPT1.php" "/index.php/%ff"; do
+build_request GET "$path" | send_request > $work/errorpage.$job.dat
+status=`head -1 $work/errorpage.$job.dat | tr -d '\r'`
+found=`fingerprint_scan $work/errorpage.$job.dat | sort -u | tr '\n' ',' | sed 's/,$//; s/,/, /g'`
+echo -e "$label\t${path:0:40}\t\t\t${status:-no answer}\t$found" >> "${out}output-errorpages.txt"
+all="$all$found, "
+done
+rm -f $work/errorpage.$job.dat
+echo "$all" | tr ',' '\n' | sed 's/^ *//; /^$/d' | sort -u | tr '\n' ',' | sed 's/,$//; s/,/, /g'
+}
+
+## publish_outputs - moves the outputs of the finished scan from the staging directory into place, ##
+## one rename per file, replacing those of the previous scan                                      ##
+publish_outputs() {
+local file
+for file in $scan_outputs; do
+if [ -e "$stage/$file" ]; then
+mv -f "$stage/$file" "$file"
+else
+rm -f "$file"
+fi
+done
+rm -rf "$stage"
+}
+
+## redact_header HEADER - prints "Name: value" with the value replaced by <redacted> for the headers that ##
+## carry credentials (Authorization, Cookie, Set-Cookie, tokens, API keys..)                             ##
+redact_header() {
+local name=${1%%:*}
+if [[ "${name,,}" =~ ^[[:space:]]*(proxy-)?authori[sz]ation$|^[[:space:]]*(set-)?cookie2?$|token|secret|passw|api-?key|session|signature ]]; then
+echo "$name: <redacted>"
+else
+echo "$1"
+fi
+}
+
+## redact_arguments - fills $redacted with the command line arguments, the values of the credential ##
+## options (--auth, --token, --cookie, --login-data, --hmac-key..) replaced by the environment      ##
+## variable that can carry them, or <redacted>, and the credentials of -H and --proxy removed       ##
+redact_arguments() {
+local i value
+redacted=()
+for (( i = 0; i < ${#config[@]}; i++ )); do
+redacted+=("${config[i]}")
+value=${config[i + 1]}
+case "${config[i]}" in
+--auth) value='$GHWS_AUTH' ;;
+--token) value='$GHWS_TOKEN' ;;
+--ntlm) value='$GHWS_NTLM' ;;
+--digest) value='$GHWS_DIGEST' ;;
+--login-data) value='$GHWS_LOGIN_DATA' ;;
+--defectdojo-token) value='$DEFECTDOJO_TOKEN' ;;
+--cookie|--hmac-key) value="<redacted>" ;;
+-H|--header) value=`redact_header "$value"` ;;
+--proxy) value=`echo "$value" | sed -E 's#^([a-z0-9]+://)?[^/@]*@#\1<redacted>@#'` ;;
+*) continue ;;
+esac
+redacted+=("$value")
+i=$(( i + 1 ))
+done
+}
+
+## crash_report STATUS COMMAND - appends the diagnostic bundle of a job or scan that died on an unexpected ##
+## error to crash-SCANID.txt: the failing command and the errors printed before it, the host and entry, ##
+## the last answer received and the configuration                                                       ##
+crash_report() {
+{
+echo -e "time\t`date -u +%Y-%m-%dT%H:%M:%SZ`\nversion\t$version\nscan-id\t$scan_id\nstatus\t$1\ncommand\t$2"
+if [ "$job_errors" != "" ] && [ -s $job_errors ]; then
+tail -5 $job_errors | sed 's/^/error\t/'
+fi
+if [ "$label" != "" ]; then
+echo -e "host\t$label\naddress\t$address\npass\t$pass\nentry\t$counter\nrequest\t$method /$line"
+if [ "$headers" != "" ]; then
+echo -ne "headers\t"
+echo "$headers" | tr '|' '\n' | while IFS= read -r header; do redact_header "$header"; done | paste -s -d'|' -
+fi
+fi
+redact_arguments
+echo -e "arguments\t${redacted[*]}\nseed\t$seed\nconfig-hash\t$config_hash"
+if [ "$job" != "" ] && [ -s $work/response.$job.dat ]; then
+echo "last answer (first 2 KB, credential headers redacted):"
+head -c 2048 $work/response.$job.dat | sed -E '1,/^\r*$/ { /^((proxy-)?authori[sz]ation|(set-)?cookie2?|www-authenticate|x-[a-z-]*token):/I s/:.*$/: <redacted>/ }' | cat -v
+echo
+fi
+echo
+} >> "crash-$scan_id.txt"
+}
+
+## discard_outputs STATUS COMMAND - on a scan that did not finish (CTRL+C, CTRL+BREAK, closed console..) ##
+## removes its temporary files (.ghws.PID) and the staged outputs, unless --keep-partial; a scan that died ##
+## on an unexpected error gets a crash report and keeps its partial outputs                                ##
+discard_outputs() {
+if [ "$1" != "130" ]; then
+crash_report "$1" "$2"
+keep_partial=1
+echo -ne "\nThe scan died on an unexpected error ($2, status $1), diagnostics in crash-$scan_id.txt\n"
+fi
+rm -rf "$work"
+if [ "$keep_partial" == "1" ]; then
+echo -ne "\nThe scan did not finish, its partial outputs are kept in $stage\n"
+else
+rm -rf "$stage"
+echo -ne "\nThe scan did not finish, its outputs were discarded (--keep-partial keeps them)\n"
+fi
+}
+
+## vhost_baseline - pins the address of the target and records the answer of an unknown Host name, ##
+## the baseline of --mode vhost                                                                     ##
+vhost_baseline() {
+local unknown="ghws-$RANDOM$RANDOM.invalid"
+if [ "$address" == "$server" ]; then
+address=`resolve_host "$server" | head -1`
+fi
+if [ "$address" == "" ]; then
+return 1
+fi
+vhost_domain=""
+if ! [[ "$server" =~ ^[0-9.]+$|: ]]; then
+vhost_domain=${server#www.}
+fi
+server=$unknown build_request GET "$vhost_path" | server=$unknown insecure=1 send_request > $work/vhost.$job.dat
+base_status=`head -1 $work/vhost.$job.dat | tr -d '\r'`
+base_size=`sed '1,/^\r*$/d' $work/vhost.$job.dat | wc -c`
+rm -f $work/vhost.$job.dat
+}
+
+## vhost_probe - requests $vhost_path with the dictionary entry as Host (word.domain for a bare word) ##
+## and reports it when its status or size departs from the baseline                                 ##
+vhost_probe() {
+local candidate=$line status size title
+if [[ "$candidate" != *.* ]] && [ "$vhost_domain" != "" ]; then
+candidate="$candidate.$vhost_domain"
+fi
+server=$candidate build_request "$method" "$vhost_path" "$headers" "$body" | server=$candidate insecure=1 send_request > $work/vhost.$job.dat
+status=`head -1 $work/vhost.$job.dat | tr -d '\r'`
+size=`sed '1,/^\r*$/d' $work/vhost.$job.dat | wc -c`
+if [ "$status" != "" ] && { [ "$status" != "$base_status" ] || [ $(( size > base_size ? size - base_size : base_size - size )) -gt $(( base_size / 20 + 32 )) ]; }; then
+title=`page_title $work/vhost.$job.dat`
+echo -e "$entry$candidate\t\t\t$status\t[size: $size${title:+, title: $title}]"
+echo "$label$sep$method$sep${vhost_path#/}$sep$status$sep$size$sep$title$sep$candidate$sep$address$sep$scheme$sep$port$sep$sep$sep" >> $work/results.dat
+fi
+rm -f $work/vhost.$job.dat
+echo "$counter" >> $work/coverage.$job.dat
+}
+
+## pair_probe - sends the entry twice, as is and with the --pair-with difference (trailing slash, other ##
+## scheme, an extra header or another method), and reports it only when the two answers differ           ##
+pair_probe() {
+local variant_line=$line variant_method=$method variant_headers=$headers variant_scheme=$scheme variant_port=$port
+local status size variant_status variant_size title
+case "$pair_with" in
+slash)
+if [[ "$line" == */ ]]; then
+variant_line=${line%/}
+else
+variant_line="$line/"
+fi
+;;
+scheme*)
+variant_scheme=https
+if [ "$scheme" == "https" ]; then
+variant_scheme=http
+fi
+variant_port=${pair_with#scheme}
+variant_port=${variant_port#:}
+if [ "$variant_port" == "" ] && [ "$variant_scheme" == "https" ]; then
+variant_port=443
+elif [ "$variant_port" == "" ]; then
+variant_port=80
+fi
+;;
+header:*) variant_headers="$headers${headers:+|}${pair_with#header:}" ;;
+method:*) variant_method=${pair_with#method:} ;;
+esac
+build_request "$method" "/$line" "$headers" "$body" | send_request > $work/pair.$job.dat
+status=`head -1 $work/pair.$job.dat | tr -d '\r'`
+size=`sed '1,/^\r*$/d' $work/pair.$job.dat | wc -c`
+title=`page_title $work/pair.$job.dat`
+scheme=$variant_scheme port=$variant_port build_request "$variant_method" "/$variant_line" "$variant_headers" "$body" | scheme=$variant_scheme port=$variant_port send_request > $work/pair.$job.dat
+variant_status=`head -1 $work/pair.$job.dat | tr -d '\r'`
+variant_size=`sed '1,/^\r*$/d' $work/pair.$job.dat | wc -c`
+rm -f $work/pair.$job.dat
+echo "$counter" >> $work/coverage.$job.dat
+if [ "$status" == "" ] && [ "$variant_status" == "" ]; then
+return
+fi
+if [ "$method" == "HEAD" ] || [ "$variant_method" == "HEAD" ]; then
+variant_size=$size
+fi
+if [ "$status" != "$variant_status" ] || [ $(( size > variant_size ? size - variant_size : variant_size - size )) -gt $(( size / 20 + 32 )) ]; then
+echo -e "$entry$line\t\t\t${status:-no answer}\t[size: $size, $pair_with: ${variant_status:-no answer}, size: $variant_size]"
+echo "$label$sep$method$sep$line$sep$status$sep$size$sep$title$sep$server$sep$address$sep$scheme$sep$port$sep$sep" >> $work/results.dat
+echo -e "$label\t$method /$line\t${status:-no answer}\t$size\t$pair_with\t$variant_method /$variant_line\t${variant_status:-no answer}\t$variant_size" >> "${out}output-pairdiff.txt"
+fi
+}
+
+## active_check NAME - succeeds when the --active-checks opt-in lists the check NAME ##
+active_check() {
+[[ ",$active_checks," == *",$1,"* ]]
+}
+
+## reflects FILE CANARY - prints where the answer in FILE reflects the canary host: "Location" for a ##
+## redirect to it, "links" for a URL of the page pointing to it                                       ##
+reflects() {
+if sed '/^\r*$/q' "$1" | grep -o -i "^Location:[ \t]*\([a-z]*:\)\?//[^/?#\r]*" | sed 's#^[^/]*//##' | grep -q -i -F "$2"; then
+echo "Location"
+elif sed '1,/^\r*$/d' "$1" | grep -o -i "\(href\|src\|action\|content\|url\)[ \t]*[=(][ \t]*[\"']\?\([a-z]*:\)\?//[^/?#\"' )>]*" | sed 's#^[^/]*//##' | grep -q -i -F "$2"; then
+echo "links"
+fi
+}
+
+## host_injection - sends the hit again with an attacker controlled Host, then X-Forwarded-Host, and ##
+## prints the headers whose canary value the answer reflects in a redirect or its links               ##
+host_injection() {
+local canary="ghws-$RANDOM.example.com" real=$server connect=$address where found=""
+if [ "$connect" == "$server" ] && [ "$unix_socket" == "" ]; then
+connect=`resolve_host "$server" | head -1`
+fi
+server=$canary build_request "$method" "/$line" "$headers" "$body" | server=$canary address=$connect sni=${sni:-$real} send_request > $work/inject.$job.dat
+where=`reflects $work/inject.$job.dat "$canary"`
+if [ "$where" != "" ]; then
+found="Host in $where"
+fi
+build_request "$method" "/$line" "$headers${headers:+|}X-Forwarded-Host: $canary" "$body" | send_request > $work/inject.$job.dat
+where=`reflects $work/inject.$job.dat "$canary"`
+if [ "$where" != "" ]; then
+found="$found${found:+, }X-Forwarded-Host in $where"
+fi
+rm -f $work/inject.$job.dat
+echo "$found"
+}
+
+## allowed_methods - sends OPTIONS for the hit and prints the methods of its Allow header (Public on old ##
+## IIS), upper case and comma separated                                                                ##
+allowed_methods() {
+build_request OPTIONS "/$line" "$headers" | send_request | sed '/^\r*$/q' | grep -i -m1 '^\(Allow\|Public\):' | sed 's/^[^:]*:[ \t]*//; s/\r$//' | tr 'a-z' 'A-Z' | tr -s ', \t' '\n' | grep -v '^$' | awk '!seen[$0]++' | paste -s -d, - | sed 's/,/, /g'
+}
+
+## dangerous_methods METHODS - prints the methods of the list that change or expose the server (PUT, ##
+## DELETE, TRACE, the WebDAV verbs..)                                                                 ##
+dangerous_methods() {
+echo "$1" | tr -d ' ' | tr ',' '\n' | grep -x 'PUT\|DELETE\|PATCH\|TRACE\|TRACK\|CONNECT\|DEBUG\|PROPPATCH\|MKCOL\|MOVE\|COPY\|LOCK\|UNLOCK\|SEARCH' | paste -s -d, - | sed 's/,/, /g'
+}
+
+## redirect_params - prints the name=value pairs of the query of the entry whose name is redirect-like ##
+## (next, url, returnTo, redirect_uri..)                                                             ##
+redirect_params() {
+local pair name IFS='&'
+if [[ "$line" != *\?* ]]; then
+return
+fi
+for pair in ${line#*\?}; do
+name=${pair%%=*}
+if [[ "${name,,}" =~ ^(url|uri|next|redir|redirect|redirect_?ur[il]|return|return_?to|return_?url|goto|go|dest|destination|continue|forward|target|to|out|r|u|rurl|callback|success_?url|checkout_?url)$ ]]; then
+echo "$pair"
+fi
+done
+}
+
+## redirect_reflection HEADERS - prints the redirect-like parameters of the entry whose (decoded) URL ##
+## value the Location of the answer starts with                                                      ##
+redirect_reflection() {
+local location pair value found=""
+location=`grep -i -m1 '^Location:' <<< "$1" | sed 's/^[^:]*:[ \t]*//; s/\r$//'`
+if [ "$location" == "" ]; then
+return
+fi
+for pair in `redirect_params`; do
+value=${pair#*=}
+value=${value//+/ }
+printf -v value '%b' "${value//%/\\x}"
+if [[ "$value" == *//* ]] && [[ "${location,,}" == "${value,,}"* ]]; then
+found="$found${found:+, }${pair%%=*}"
+fi
+done
+echo "$found"
+}
+
+## open_redirect - sends the entry again with a canary URL in each of its redirect-like parameters and ##
+## prints those the answer redirects (or links) to                                                    ##
+open_redirect() {
+local canary="ghws-$RANDOM.example.com" pair variant where found=""
+for pair in `redirect_params`; do
+variant=${line/"$pair"/"${pair%%=*}=https%3A%2F%2F$canary%2F"}
+build_request "$method" "/$variant" "$headers" "$body" | send_request > $work/inject.$job.dat
+where=`reflects $work/inject.$job.dat "$canary"`
+if [ "$where" != "" ]; then
+found="$found${found:+, }${pair%%=*} in $where"
+fi
+done
+rm -f $work/inject.$job.dat
+echo "$found"
+}
 
-echo -ne "Script: $0\tURL: $1\n"
-server=$1
+## redirect_chain FILE - follows the Location of the answer in FILE (the request being $method /$line) ##
+## up to --max-redirects hops and prints the chain: "status location -> ... -> final status"; from the  ##
+## first hop to another host the requests carry no credentials, headers, cookies or signature          ##
+redirect_chain() {
+local hop=$work/redirect.$BASHPID.dat hops=0 chain="" status location rest path="/$line" method=$method body=$body
+local server=$server address=$address scheme=$scheme port=$port
+cp "$1" $hop
+while true; do
+status=`head -1 $hop | tr -d '\r'`
+location=`sed -n '1,/^\r*$/p' $hop | tr -d '\r' | grep -i -m 1 '^location:' | sed 's/^[^:]*:[ \t]*//'`
+if ! [[ "${status:9:3}" =~ ^3 ]] || [ "$location" == "" ]; then
+chain="$chain${status:9:3}"
+break
+fi
+chain="$chain${status:9:3} $location -> "
+if [ $hops -ge $max_redirects ]; then
+chain="${chain}max redirects"
+break
+fi
+hops=`expr $hops + 1`
+case "$location" in
+//*) location="$scheme:$location" ;;
+esac
+case "$location" in
+http://*|https://*)
+scheme=${location%%://*}
+rest=${location#*://}
+path=/${rest#*/}
+if [[ "$rest" != */* ]]; then
+path=/
+fi
+rest=${rest%%/*}
 port=80
+if [ "$scheme" == "https" ]; then
+port=443
+fi
+if [[ "$rest" == *:* ]]; then
+port=${rest##*:}
+rest=${rest%:*}
+fi
+if [ "$rest" != "$server" ]; then
+local headers="" custom_headers=() cookies="" authorization="" ntlm="" digest="" hmac_key="" signer="" aws_sigv4=""
+local -A session_cookies=()
+server=$rest
+address=`resolve_host "$server" | head -1`
+if [ "$address" == "" ]; then
+chain="${chain}unresolvable"
+break
+fi
+if [ "$exclude_hosts" != "" ] && { excluded "$server" || excluded "$address"; }; then
+chain="${chain}out of scope"
+break
+fi
+fi
+;;
+/*) path=$location ;;
+*) path="${path%/*}/$location" ;;
+esac
+if ! [[ "${status:9:3}" =~ ^30[78]$ ]]; then
+method=GET
+body=""
+fi
+build_request "$method" "${path%%#*}" "$headers" "$body" | send_request > $hop
+done
+rm -f $hop
+echo "$chain"
+}
+
+## connect_failure - prints why nothing answered on $address:$port: refused, timed out, unreachable, or ##
+## the TLS handshake (certificate verification without --insecure) failing once connected             ##
+connect_failure() {
+local error
+if [ "$proxy$transport$unix_socket" != "" ]; then
+echo "no answer"
+elif error=`timeout 5 bash -c 'exec 3<> "/dev/tcp/$1/$2"' _ "$address" "$port" 2>&1`; then
+if [ "$scheme" == "https" ] && [ "$insecure" != "1" ]; then
+echo "TLS handshake or certificate verification failed (--insecure)"
+elif [ "$scheme" == "https" ]; then
+echo "TLS handshake failed"
+else
+echo "connected, but no HTTP answer"
+fi
+elif [ $? == 124 ]; then
+echo "connection timed out"
+elif [[ "$error" == *refused* ]]; then
+echo "connection refused"
+else
+echo "unreachable"
+fi
+}
+
+## preflight_check - requests / before the dictionary, trying https then http (on the target port, else 443 ##
+## and 80) when the target gave no scheme; fails with $preflight_reason when the host does not answer       ##
+preflight_check() {
+local candidates="$scheme:$port" candidate failures=""
+if [ "$target_scheme" == "" ] && [ "$scheme_given" != "1" ] && [ "$unix_socket" == "" ]; then
+candidates="https:`target_port https "$target_port"` http:`target_port http "$target_port"`"
+fi
+if [ "$proxy$transport$unix_socket" == "" ] && [ "$address" == "$server" ] && [ "`resolve_host "$server" | head -1`" == "" ]; then
+preflight_reason="$server does not resolve"
+return 1
+fi
+for candidate in $candidates; do
+scheme=${candidate%:*}
+port=${candidate#*:}
+status=`build_request GET / | send_request | head -1`
+status=${status%$'\r'}
+if [ "$status" != "" ]; then
+return 0
+fi
+failures="$failures${failures:+, }$scheme port $port: `connect_failure`"
+done
+preflight_reason="no answer to GET / ($failures)"
+return 1
+}
+
+## run_host - scans the host in its own job; when the job dies on an unexpected error (a malformed answer ##
+## hitting an edge case..) it writes a crash report, saves the entries left in output-retry.jsonl, without ##
+## the one it died on, and lets the scan go on with the other hosts                                         ##
+run_host() {
+local job_errors=$work/errors.$BASHPID.dat
+trap 'host_crashed $? "$BASH_COMMAND"' EXIT
+scan_host 2> >(tee $job_errors >&2)
+trap - EXIT
+rm -f $job_errors
+}
+
+## host_crashed STATUS COMMAND - the EXIT trap of run_host ##
+host_crashed() {
+if [ "$1" == "130" ] || [ "$1" == "143" ]; then
+return
+fi
+sleep 0.2
+crash_report "$1" "$2"
+echo -ne "$label\t$method /$line\t\tStopped: the job died on an unexpected error ($2, status $1), diagnostics in crash-$scan_id.txt\n"
+skip "$label" "*" "*" "crashed on entry $counter (/$line)"
+if [ "$pass" != "" ]; then
+backlog rest
+fi
+if [ -s $work/backfill.$job.dat ]; then
+retry_file
+fi
+rm -f "$work"/[a-z]*.$job.dat "$work"/[a-z]*.$job.dat.size $job_errors
+}
+
+## scan_host - runs the dictionary against $server, connecting to $address when it is already known ##
+scan_host() {
+echo -ne "Script: $0\tURL: $scheme://$server:$port${http_version:+\tHTTP/$http_version}\n"
+job=$BASHPID
+session_cookies=()
+if [ "$cookie_jar" != "" ]; then
+load_cookies
+fi
+RANDOM=$(( (seed + `echo "$server" | cksum | cut -d' ' -f1`) % 2147483648 ))
+agent_seed=$RANDOM
+if [ "${#proxies[@]}" -gt 0 ]; then
+proxy_turn=$(( `echo "$server" | cksum | cut -d' ' -f1` % ${#proxies[@]} ))
+fi
+if [ "${#source_ips[@]}" -gt 0 ]; then
+source_turn=$(( `echo "$server" | cksum | cut -d' ' -f1` % ${#source_ips[@]} ))
+fi
+label=$server
+if [ "$scheme" != "http" ] || [ "$port" != "80" ]; then
+label="$scheme://$server:$port"
+fi
+if [ "$unix_socket" != "" ]; then
+address=$server
+label="$label (unix:$unix_socket)"
+elif [ "$address" != "" ]; then
+label="$label ($address)"
+if [ "$exclude_hosts" != "" ] && { excluded "$server" || excluded "$address"; }; then
+echo -ne "$label\t\t\tSkipped: excluded host\n"
+skip "$label" "*" "*" "excluded host"
+return
+fi
+elif [ "$exclude_hosts" != "" ] || [ "$resolver" != "" ] || [ "$doh" != "" ]; then
+if ! check_scope; then
+echo -ne "$server\t\t\tSkipped: $skip_reason\n"
+skip "$server" "*" "*" "$skip_reason"
+return
+fi
+else
+address=$server
+dns_cached=0
+if [ "$proxy$transport" == "" ] && [ "$prefer_family" == "" ] && { [ "$dns_ttl" != "0" ] || [ "$pin_dns" == "1" ]; }; then
+cache_address
+if [ "$pin_dns" == "1" ] && [ "$address" != "$server" ]; then
+label="$label ($address)"
+elif [ "$address" != "$server" ]; then
+dns_cached=1
+fi
+fi
+fi
+if [ "$prefer_family" != "" ] && [ "$label" == "${label% (*)}" ]; then
+if ! prefer_family; then
+echo -ne "$label\t\t\tSkipped: no answer over IPv4 or IPv6\n"
+skip "$label" "*" "*" "no answer over IPv4 or IPv6"
+return
+fi
+label="$label ($address)"
+fi
+egress=`egress_address`
+host_agent="-"
+if [ "$transport" == "" ] && uses_curl; then
+host_agent=$curl_agent
+fi
+if [ "$preflight" == "1" ] && [ "$mode" != "vhost" ]; then
+if ! preflight_check; then
+echo -ne "$label\t\t\tSkipped: $preflight_reason\n"
+skip "$label" "*" "*" "preflight: $preflight_reason"
+return
+fi
+suffix=${label#"${label%% (*}"}
+label=$server
+if [ "$scheme" != "http" ] || [ "$port" != "80" ]; then
+label="$scheme://$server:$port"
+fi
+label="$label$suffix"
+echo -ne "$label\t\t\tPreflight: GET / answered $status\n"
+fi
+if [ "$login_url" != "" ]; then
+if ! login_session; then
+echo -ne "$label\t\t\tSkipped: $login_reason\n"
+skip "$label" "*" "*" "$login_reason"
+return
+fi
+echo -ne "$label\t\t\tLogin: POST $login_url answered $login_status, session: ${!session_cookies[*]}\n"
+fi
+if [ "$success_expr" != "" ]; then
+success_baseline
+echo -ne "$label\t\t\tBaseline (random path): ${baseline_code:-no answer}, $baseline_length bytes\n"
+fi
 counter=0
+slowdown=1
+backoff=1
+calm=0
+failures=0
+tripped=0
+expired=0
+restores=0
+recent=""
+touch $work/coverage.$job.dat
+if [ "$error_mining" == "1" ]; then
+fingerprints=`error_pages`
+echo -ne "$label\t\t\tError pages: ${fingerprints:-no framework found}\n"
+fi
+if [ "$header_diff" == "1" ]; then
+build_request GET "/" | send_request > $work/response.$job.dat
+header_profile $work/response.$job.dat > $work/baseline.$job.dat
+rm -f $work/response.$job.dat $work/response.$job.dat.size
+fi
+if [ "$mode" == "vhost" ]; then
+if ! vhost_baseline; then
+echo -ne "$server\t\t\tSkipped: unresolvable host\n"
+skip "$server" "*" "*" "unresolvable host"
+return
+fi
+echo -ne "$label\t\t\tBaseline (unknown Host): ${base_status:-no answer}, $base_size bytes\n"
+fi
+default_site=""
+if [ "$vhost_diff" == "1" ]; then
+default_site=`getent ahosts "$address" | awk 'NR == 1 { print $1 }'`
+if [ "$default_site" == "$server" ]; then
+default_site=""
+fi
+fi
 
-while read line; do
-sleep 0.10
-counter=`expr $counter + 1`
-echo -ne "$line\t\t\t"
-echo -e "GET /$line HTTP/1.0\nHost: $server\n" | netcat $server $port | head -1
+depth=0
+for pass in dictionary `for (( round = 0; round < follow_up_depth; round++ )); do echo follow-ups; done` `for (( round = 0; round < backfill_rounds; round++ )); do echo backfill; done`; do
+if [ "$pass" == "follow-ups" ]; then
+depth=$(( depth + 1 ))
+follow_ups
+if ! [ -s $work/following.$job.dat ]; then
+continue
+fi
+echo -ne "$label\t\t\tFollow-ups: `wc -l < $work/following.$job.dat` paths asked by the analyzers (level $depth)\n"
+fi
+if [ "$pass" == "backfill" ]; then
+if ! [ -s $work/backfill.$job.dat ]; then
+break
+fi
+mv -f $work/backfill.$job.dat $work/backfilling.$job.dat
+echo -ne "$label\t\t\tBackfill: retrying `wc -l < $work/backfilling.$job.dat` dropped entries at 1/$(( slowdown * backoff )) of the rate\n"
+fi
+pass_start=$counter
+while IFS=$sep read -r line method headers body; do
+if [ "${#proxies[@]}" -gt 0 ] && ! rotate_proxy; then
+echo -ne "$label\t\t\tStopped: every proxy of $proxy_file is dead\n"
+backlog rest
+break 2
+fi
+delay=$(( 100 * slowdown * backoff ))
+if [ "$rate" != "" ]; then
+delay=0
+for (( i = 0; i < slowdown * backoff; i++ )); do
+take_slot requests "$rate"
+done
+fi
+if [ "$jitter" -gt 0 ]; then
+delay=$(( delay + RANDOM % (jitter + 1) ))
+fi
+printf -v pause "%d.%03d" $(( delay / 1000 )) $(( delay % 1000 ))
+sleep $pause
+counter=$(( counter + 1 ))
+if [ "$dns_cached" == "1" ] && [ $(( EPOCHSECONDS - resolved_at )) -ge $dns_ttl ]; then
+cache_address
+fi
+if [ "$tor_newnym" != "" ] && [ $counter -gt 1 ] && [ $(( (counter - 1) % tor_newnym )) == 0 ]; then
+if reply=`new_circuit`; then
+echo -ne "$label\t\t\tNew Tor circuits after $(( counter - 1 )) requests\n"
+else
+echo -ne "$label\t\t\tNo new Tor circuits: $reply\n"
+fi
+fi
+if [ "$rotate_source" == "1" ]; then
+source_turn=$(( source_turn + 1 ))
+fi
+entry=""
+if [ "$multi_host" == "1" ]; then
+entry="$label\t"
+fi
+if [ "$method" != "$default_method" ]; then
+entry="$entry$method "
+fi
+if [ "$body" == "" ] && [ "$default_body" != "" ] && [ "$method" != "HEAD" ]; then
+body=$default_body
+fi
+if [ "$body" != "" ] && [ "$content_type" != "" ] && ! [[ "|$headers" =~ \|[[:space:]]*[Cc]ontent-[Tt]ype: ]]; then
+headers="$headers${headers:+|}Content-Type: $content_type"
+fi
+if [ "$mode" == "vhost" ]; then
+vhost_probe
+continue
+fi
+if [ "$mode" == "pair" ]; then
+pair_probe
+continue
+fi
+language=""
+anomalies=""
+disclosure=""
+redirects=""
+entropy=""
+analysis=""
+whole=0
+if [ "$fleet_dedup" == "1" ] || [ "$detect_language" == "1" ] || [ "$header_diff" == "1" ] || [ "$check_disclosure" == "1" ] || [ "$follow_redirects" == "1" ] || [ "$check_entropy" == "1" ] || [ "${#analyzers[@]}" -gt 0 ] || [ "$success_expr" != "" ]; then
+whole=1
+fi
+relogged=0
+for (( retries = 0; ; retries++ )); do
+started=$EPOCHREALTIME
+if [ "$whole" == "1" ]; then
+build_request "$method" "/$line" "$headers" "$body" | send_request | keep_body $work/response.$job.dat
+answer=`sed '/^\r*$/q' $work/response.$job.dat`
+else
+answer=`build_request "$method" "/$line" "$headers" "$body" | send_request | sed '/^\r*$/q'`
+fi
+status=${answer%%$'\n'*}
+status=${status%$'\r'}
+duration=$(( (${EPOCHREALTIME/./} - ${started/./}) / 1000 ))
+echo -e "requests\t$(( ${EPOCHREALTIME/./} - ${started/./} ))" >> $work/profile.$job.dat
+TZ=UTC printf -v started_at '%(%Y-%m-%dT%H:%M:%S)T' "${started%.*}"
+started_at="$started_at.${started:${#started}-6:3}Z"
+timing=`last_timing`
+if [ "$login_url" != "" ] && [ "$relogged" == "0" ] && logged_out "$answer"; then
+relogged=1
+echo -ne "$label\t$method /$line\t\t${status:9}, the session expired, logging in again\n"
+if ! login_session; then
+expired=1
+break
+fi
+continue
+elif [ "$relogged" == "1" ] && logged_out "$answer"; then
+restores=$(( restores + 1 ))
+else
+restores=0
+fi
+if [ "$adaptive" != "1" ] || ! [[ "${status:9:3}" =~ ^(429|503)$ ]] || [ $retries -ge 3 ]; then
+break
+fi
+calm=0
+hold=`retry_after "$answer"`
+reason="Retry-After"
+if [ "$hold" == "" ]; then
+hold=$(( 2 << retries ))
+reason="backoff"
+fi
+if [ $hold -gt $max_backoff ]; then
+hold=$max_backoff
+fi
+if [ $backoff -lt 64 ]; then
+backoff=$(( backoff * 2 ))
+fi
+echo -ne "$label\t$method /$line\t\t${status:9}, waiting ${hold}s ($reason) and retrying at 1/$(( slowdown * backoff )) of the rate\n"
+sleep $hold
+done
+if [ "$cookie_jar$login_url" != "" ]; then
+store_cookies "$answer"
+fi
+if [ "$expired" == "1" ] || [ $restores -ge 3 ]; then
+if [ "$expired" == "1" ]; then
+echo -ne "$label\t\t\tStopped: the session expired and $login_reason\n"
+else
+echo -ne "$label\t\t\tStopped: logging in again does not restore the session (--logged-out)\n"
+fi
+skip "$label" "*" "*" "session expired after $counter requests"
+rm -f $work/response.$job.dat $work/response.$job.dat.size
+backlog
+backlog rest
+break 2
+fi
+persistent=""
+if [[ "${status:9:3}" =~ ^(429|503)$ ]] && [ "$adaptive" == "1" ]; then
+persistent="\t[still ${status:9:3} after 3 retries]"
+fi
+if [ "$status" == "" ]; then
+rm -f $work/response.$job.dat $work/response.$job.dat.size
+backlog
+failures=$(( failures + 1 ))
+if [ "$max_failures" -gt 0 ] && { [ $failures -ge $max_failures ] || [ "$tripped" == "1" ]; }; then
+if [ "$tripped" == "1" ] || [ "$down_wait" == "0" ]; then
+echo -ne "$label\t\t\tStopped: $failures consecutive requests got no answer, the host looks down\n"
+skip "$label" "*" "*" "down after $counter requests"
+backlog rest
+break 2
+fi
+echo -ne "$label\t\t\tPaused: $failures consecutive requests got no answer, trying again in ${down_wait}s\n"
+sleep $down_wait
+tripped=1
+fi
+continue
+fi
+failures=0
+tripped=0
+if [ "$backoff" -gt 1 ]; then
+calm=$(( calm + 1 ))
+if [ $calm -ge 20 ]; then
+backoff=$(( backoff / 2 ))
+calm=0
+echo -ne "$label\t\t\tNo throttling in 20 answers, back to 1/$(( slowdown * backoff )) of the rate\n"
+fi
+fi
+if [ "$whole" == "1" ]; then
+if [ "$fleet_dedup" == "1" ]; then
+echo "$label$sep$line$sep$status$sep`sed '1,/^\r*$/d' $work/response.$job.dat | md5sum | cut -d' ' -f1`" >> $work/fingerprints.dat
+fi
+if [ "$detect_language" == "1" ] && [ "${status:9:3}" != "404" ]; then
+language=`page_language $work/response.$job.dat`
+fi
+if [ "$header_diff" == "1" ] && [ "${status:9:3}" != "404" ] && [ "$status" != "" ]; then
+anomalies=`header_anomalies $work/baseline.$job.dat <(header_profile $work/response.$job.dat)`
+fi
+if [ "$check_entropy" == "1" ] && [ "$method" != "HEAD" ] && [ "${status:9:3}" != "404" ]; then
+entropy=`body_entropy $work/response.$job.dat`
+if [ "$entropy" != "" ] && awk -v bits="$entropy" -v min="$entropy_min" 'BEGIN { exit !(bits < min) }'; then
+entropy=""
+fi
+fi
+if [ "$check_disclosure" == "1" ]; then
+disclosure=`secrets_scan $work/response.$job.dat disclosure.rules | tr '\n' ' ' | sed 's/ $//; s/ /, /g'`
+fi
+if [ "$follow_redirects" == "1" ] && [[ "${status:9:3}" =~ ^3 ]]; then
+redirects=`redirect_chain $work/response.$job.dat`
+fi
+if [ "$success_expr" != "" ]; then
+result_code=${status:9:3}
+result_length=`body_size $work/response.$job.dat`
+result_path=/$line
+result_type=`grep -i -m1 '^Content-Type:' $work/response.$job.dat | sed 's/^[^:]*:[ \t]*//; s/\r$//'`
+result_title=""
+if [[ "${success_parts[0]}" == *result_title* ]]; then
+result_title=`page_title $work/response.$job.dat`
+fi
+if [[ "$result_code" =~ ^[0-9]{3}$ ]] && [ "${baselines[$result_code]}" == "" ]; then
+baselines[$result_code]=$result_length
+fi
+fi
+if [ "${#analyzers[@]}" -gt 0 ] && [ "${status:9:3}" != "404" ] && [ "$status" != "" ]; then
+analysis=`run_analyzers $work/response.$job.dat`
+fi
+rm -f $work/response.$job.dat $work/response.$job.dat.size
+fi
+details=""
+size=""
+title=""
+injection=""
+if [ "$timestamps" == "1" ]; then
+details="\t[at: $started_at, $duration ms]"
+fi
+if [ "$timing" != "" ]; then
+details="$details\t[time: $timing]"
+fi
+details="$details$persistent"
+if [ "$method" == "HEAD" ] && [ "$escalate" == "1" ] && [[ "${status:9:3}" =~ ^(2..|3..|401|403)$ ]]; then
+build_request GET "/$line" "$headers" "$body" | send_request | keep_body $work/escalate.$job.dat
+size=`body_size $work/escalate.$job.dat`
+title=`page_title $work/escalate.$job.dat`
+details="$details\t[GET size: $size, title: $title]"
+rm -f $work/escalate.$job.dat $work/escalate.$job.dat.size
+fi
+allow=""
+if [ "$check_methods" == "1" ] && [[ "${status:9:3}" =~ ^(200|401|403)$ ]]; then
+allow=`allowed_methods`
+if [ "$allow" != "" ]; then
+details="$details\t[allow: $allow]"
+candidate=`dangerous_methods "$allow"`
+if [ "$candidate" != "" ]; then
+details="$details\t[dangerous methods: $candidate]"
+fi
+fi
+fi
+if active_check host-header && [[ "${status:9:3}" =~ ^[23] ]]; then
+injection=`host_injection`
+if [ "$injection" != "" ]; then
+details="$details\t[host header injection: $injection]"
+echo -e "$label\t$method /$line\t$status\thost-header\t$injection" >> "${out}output-active.txt"
+fi
+fi
+if [[ "${status:9:3}" =~ ^3 ]] && ! active_check open-redirect; then
+candidate=`redirect_reflection "$answer"`
+if [ "$candidate" != "" ]; then
+details="$details\t[open redirect candidate: $candidate]"
+fi
+fi
+if active_check open-redirect && [ "${status:9:3}" != "404" ] && [ "$status" != "" ] && [ "`redirect_params`" != "" ]; then
+candidate=`open_redirect`
+if [ "$candidate" != "" ]; then
+details="$details\t[open redirect candidate: $candidate]"
+echo -e "$label\t$method /$line\t$status\topen-redirect\t$candidate" >> "${out}output-active.txt"
+fi
+fi
+if [ "$redirects" != "" ]; then
+details="$details\t[redirects: $redirects]"
+fi
+if [ "$entropy" != "" ]; then
+details="$details\t[high entropy: $entropy bits/char]"
+fi
+if [ "$language" != "" ]; then
+details="$details\t[lang: $language]"
+fi
+details="$details$analysis"
+if [ "$disclosure" != "" ]; then
+details="$details\t[disclosure: $disclosure]"
+echo -e "$label\t$method /$line\t\t\t$status\t$disclosure" >> "${out}output-disclosure.txt"
+fi
+if [ "$anomalies" != "" ]; then
+details="$details\t[headers: $anomalies]"
+echo -e "$label\t$method /$line\t\t\t$status\t$anomalies" >> "${out}output-headerdiff.txt"
+fi
+if [ "${status:9:3}" != "404" ] && [ -f "$state_file" ]; then
+state=`state_of "$(finding_url)"`
+if [ "$state" != "new" ]; then
+details="$details\t[$state]"
+fi
+fi
+merged=0
+if [ "$variants" != "" ]; then
+variant_check
+fi
+if ! wanted_language "$language"; then
+if [ "$log_all" == "1" ]; then
+echo -e "$entry$line\t\t\t$status$details"
+fi
+elif [ "$merged" == "0" ] && { [ "$log_all" == "1" ] || is_hit || [ "$disclosure" != "" ]; }; then
+echo -e "$entry$line\t\t\t$status$details"
+fi
+if [ "$deep_trigger" != "" ] && [[ "${status:9:3}" =~ $deep_trigger ]] && wanted_language "$language"; then
+echo "$label$sep$server$sep$address$sep$scheme$sep$port$sep$method$sep$line$sep$headers$sep$body$sep$status" >&3
+fi
+echo "$counter" >> $work/coverage.$job.dat
+if [ "$default_site" != "" ]; then
+default_status=`server=$default_site build_request "$method" "/$line" "$headers" "$body" | send_request | head -1 | tr -d '\r'`
+if [ "${default_status:9:3}" != "${status:9:3}" ]; then
+note=""
+if [ "${status:9:3}" == "404" ]; then
+note="\t[only reachable by IP]"
+fi
+echo -e "$label\t$method /$line\t\t\t$server: $status\t$default_site: $default_status$note" >> "${out}output-vhostdiff.txt"
+fi
+fi
+echo "$label$sep$method$sep$line$sep$status$sep$size$sep$title$sep$server$sep$address$sep$scheme$sep$port$sep$language$sep$disclosure$sep$redirects$sep$entropy$sep$started_at$sep$duration$sep$allow" >> $work/results.dat
+if [ "$storm_threshold" != "" ]; then
+if [[ "${status:9:1}" == "5" ]]; then
+recent="${recent}1"
+else
+recent="${recent}0"
+fi
+if [ ${#recent} -gt $storm_window ]; then
+recent=${recent:1}
+fi
+errors=${recent//0/}
+if [ ${#recent} -ge $storm_window ] && [ $(( ${#errors} * 100 )) -gt $(( storm_threshold * storm_window )) ]; then
+if [ "$storm_resume" != "1" ]; then
+echo -ne "$label\t\t\tStopped: ${#errors} of the last $storm_window answers were 5xx\n"
+skip "$label" "*" "*" "5xx storm after $counter requests"
+backlog rest
+break 2
+fi
+echo -ne "$label\t\t\tPaused: ${#errors} of the last $storm_window answers were 5xx, cooling down for ${cooldown}s\n"
+sleep $cooldown
+slowdown=$(( slowdown * 2 ))
+recent=""
+echo -ne "$label\t\t\tResumed at 1/$slowdown of the rate\n"
+fi
+fi
 
-done < "hybridWebSearch.dic" | tee .log.dat
+done < <(pass_entries)
+done
+if [ -s $work/backfill.$job.dat ]; then
+echo -ne "$label\t\t\tBackfill: `wc -l < $work/backfill.$job.dat` entries left, saved in output-retry.jsonl\n"
+retry_file
+fi
+coverage_summary >> "${out}output-coverage.txt"
+if [ "$timings" == "1" ] && [ -s $work/timings.$job.dat ]; then
+timing_summary | tee -a "${out}output-timings.txt" | sed 's/\t/\t\t\tTimings: /'
+fi
+if [ "$cookie_jar" != "" ]; then
+save_cookies
+fi
+if [ -f $work/profile.$job.dat ]; then
+cat $work/profile.$job.dat >> $work/profile.dat
+fi
+rm -f $work/profile.$job.dat $work/coverage.$job.dat $work/baseline.$job.dat $work/timings.$job.dat $work/followups.$job.dat $work/following.$job.dat $work/followed.$job.dat $work/backfill.$job.dat $work/backfilling.$job.dat
+}
+
+mkdir -p "$work"
+trap 'rm -rf "$work"' EXIT
+if [ "$tor" == "1" ]; then
+if [ "$proxy" != "" ] || [ "$proxy_file" != "" ]; then
+echo -ne "--tor cannot be used with --proxy or --proxy-file\n"
+exit 1
+fi
+proxy="socks5://$tor_socks"
+fi
+if [ "$proxy" != "" ]; then
+if ! line=`proxy_url "$proxy"`; then
+echo -ne "Unknown proxy: $proxy (socks5://[user:pass@]host:port)\n"
+exit 1
+fi
+proxy=$line
+fi
+if [ "$proxy_file" != "" ]; then
+while read -r line; do
+case "$line" in
+""|"#"*) continue ;;
+esac
+if ! proxies+=("`proxy_url "$line"`"); then
+echo -ne "Unknown proxy in $proxy_file: $line\n"
+exit 1
+fi
+done < <(tr -d '\r' < "$proxy_file" | sed 's/^[ \t]*//; s/[ \t]*$//')
+if [ "${#proxies[@]}" == "0" ]; then
+echo -ne "No proxy in $proxy_file\n"
+exit 1
+fi
+proxy=${proxies[0]}
+rm -f $work/deadproxies.dat
+touch $work/deadproxies.dat
+fi
+if [ "$command" == "verify" ]; then
+verify_results "$verify_file"
+exit
+elif [ "$command" == "state" ]; then
+state_command "${state_args[@]}"
+exit
+elif [ "$command" == "query" ]; then
+query_results "${query_args[@]}"
+exit
+elif [ "$command" == "digest" ]; then
+digest_report "${digest_args[@]}"
+exit
+fi
+
+stage=".partial.$$"
+mkdir "$stage"
+out="$stage/"
+trap 'discard_outputs $? "$BASH_COMMAND"' EXIT
+trap 'exit 130' INT TERM HUP QUIT
+redact_arguments
+reproduce=""
+for (( i = 0; i < ${#redacted[@]}; i++ )); do
+case "${redacted[i]}" in
+--seed|--profile) i=$(( i + 1 )) ;;
+\$[A-Z_]*) reproduce="$reproduce \"${redacted[i]}\"" ;;
+*) reproduce="$reproduce `printf "%q" "${redacted[i]}"`" ;;
+esac
+done
+echo -e "version\t$version\nseed\t$seed\nconfig-hash\t$config_hash\nscan-id\t$scan_id\narguments\t${redacted[*]}\nreproduce\t./${0##*/} --seed $seed$reproduce" > "${out}output-config.txt"
+echo -ne "Seed: $seed\tConfig hash: $config_hash\tScan id: $scan_id\n"
+correlation="${out}output-correlation.csv"
+echo "time,log_time,source,method,url,path,user_agent,status,scan_id" > "$correlation"
+touch $work/fingerprints.dat $work/results.dat
+if [ "$deep_trigger" != "" ]; then
+rm -f $work/deep.fifo
+mkfifo $work/deep.fifo
+deep_stage < $work/deep.fifo &
+deep_pid=$!
+exec 3> $work/deep.fifo
+fi
+
+{
+while IFS=$sep read -r server address target_scheme target_port; do
+scheme=${target_scheme:-$default_scheme}
+port=`target_port "$scheme" "$target_port"`
+if [ "$address" == "" ] && [ "$resolves" != "" ]; then
+address=`resolve_override "$server" "$port"`
+fi
+pinned_address=0
+if [ "$address" != "" ]; then
+pinned_address=1
+fi
+run_host &
+while [ `jobs -rp | wc -l` -ge $threads ]; do
+wait -n
+done
+done < <(targets)
+wait
+} | tee "$out$log_file"
+
+if [ "$deep_trigger" != "" ]; then
+exec 3>&-
+echo -ne "Waiting for the deep analysis stage to finish..\n"
+wait $deep_pid
+rm -f $work/deep.fifo
+fi
 
-cat .log.dat | grep -i "200 OK" > output-200.txt
+cat "$out$log_file" | grep -i "200 OK" > "${out}output-200.txt"
 sleep 0.10
-cat .log.dat | grep -v -i "404 Not Found" > output-ex404.txt
+cat "$out$log_file" | grep -v -i "404 Not Found" > "${out}output-ex404.txt"
 
-#rm .log.dat   ## in case the main log file in not needed to be kept for further searches
+timed "sink results" results_jsonl > "${out}output-results.jsonl"
+if [ "$create_issues" != "" ]; then
+timed "sink issues" create_issues
+fi
+if [ "$defectdojo" == "1" ]; then
+timed "sink defectdojo" defectdojo_export > "${out}output-defectdojo.json"
+if [ "$defectdojo_url" != "" ]; then
+timed "sink defectdojo" defectdojo_upload
+fi
+fi
+if [ "$multi_host" == "1" ]; then
+timed "sink matrix" fleet_matrix > "${out}output-matrix.csv"
+fi
+if [ "$top" -gt 0 ]; then
+timed "sink top" top_findings > "${out}output-top.txt"
+if [ -s "${out}output-top.txt" ]; then
+echo -ne "\nTop $top most interesting findings (score, status, URL, reasons):\n"
+cat "${out}output-top.txt"
+fi
+fi
+if [ "$fleet_dedup" == "1" ]; then
+timed "sink fleet" fleet_report > "${out}output-fleet.txt"
+fi
+module_profile > "${out}output-profile.txt"
+if [ -s "${out}output-profile.txt" ]; then
+echo -ne "\nTime per module (summed over the jobs, share, calls):\n"
+cat "${out}output-profile.txt"
+fi
+publish_outputs
+trap - EXIT
+rm -rf "$work"
 
+#rm $log_file   ## in case the main log file in not needed to be kept for further searches
 
 